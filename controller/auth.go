@@ -1,16 +1,26 @@
 package controller
 
 import (
-	"errors"
+	"context"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/domain"
 	"github.com/ricky2122/go-echo-example/usecase"
 )
 
-const SessionKey = "session_id"
+const (
+	// SessionKey is the name of the session cookie
+	SessionKey = "session_id"
+
+	// keys of the session values
+	SessionUserIDKey   = "user_id"
+	SessionIssuedAtKey = "issued_at"
+	SessionAuthTimeKey = "auth_time"
+)
 
 type LoginRequest struct {
 	Name     string `json:"name" validate:"required"`
@@ -18,7 +28,8 @@ type LoginRequest struct {
 }
 
 type IAuthUseCase interface {
-	Login(input usecase.LoginUseCaseInput) error
+	Login(ctx context.Context, input usecase.LoginUseCaseInput) (domain.UserID, error)
+	GetLoginUser(ctx context.Context, input usecase.GetLoginUserUseCaseInput) (*usecase.GetLoginUserUseCaseOutput, error)
 }
 
 type AuthController struct {
@@ -33,7 +44,7 @@ func (ac *AuthController) Login(c echo.Context) error {
 	// Parse request
 	req := new(LoginRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+		return invalidRequest(err)
 	}
 
 	// Validate
@@ -42,18 +53,28 @@ func (ac *AuthController) Login(c echo.Context) error {
 	}
 
 	// Login usecase
-	input := usecase.LoginUseCaseInput{Name: req.Name, Password: req.Password}
-	if err := ac.au.Login(input); err != nil {
-		if errors.Is(err, usecase.ErrLoginFailed) {
-			return echo.NewHTTPError(http.StatusUnauthorized, "failed login")
-		}
-		return echo.NewHTTPError(http.StatusBadRequest, "internal server error")
+	input := usecase.LoginUseCaseInput{Name: req.Name, Password: req.Password, IP: c.RealIP()}
+	userID, err := ac.au.Login(c.Request().Context(), input)
+	if err != nil {
+		return err
 	}
 
-	// Set session_id to cookie
+	// Set user id to a new session, so that a session ID planted before the login is never logged in (session fixation)
 	sess, err := session.Get(SessionKey, c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
+	}
+	if sess.ID != "" {
+		sess.Options = &sessions.Options{
+			Path:     "/",
+			MaxAge:   -1,
+			HttpOnly: true,
+		}
+		if err := sess.Save(c.Request(), c.Response()); err != nil {
+			return err
+		}
+		sess.ID = ""
+		sess.Values = map[interface{}]interface{}{}
 	}
 	sess.Options = &sessions.Options{
 		Path:     "/",
@@ -61,9 +82,12 @@ func (ac *AuthController) Login(c echo.Context) error {
 		HttpOnly: true,
 		// Secure:   true,
 	}
-	sess.Values[SessionKey] = input.Name
+	now := time.Now().Unix()
+	sess.Values[SessionUserIDKey] = userID.Int()
+	sess.Values[SessionIssuedAtKey] = now
+	sess.Values[SessionAuthTimeKey] = now
 	if err := sess.Save(c.Request(), c.Response()); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
 	}
 
 	// Send response
@@ -74,7 +98,7 @@ func (ac *AuthController) Logout(c echo.Context) error {
 	// delete session
 	sess, err := session.Get(SessionKey, c)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
 	}
 	sess.Options = &sessions.Options{
 		Path:     "/",
@@ -82,7 +106,7 @@ func (ac *AuthController) Logout(c echo.Context) error {
 		HttpOnly: true,
 	}
 	if err := sess.Save(c.Request(), c.Response()); err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
 	}
 
 	// send response
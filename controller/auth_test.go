@@ -1,6 +1,7 @@
 package controller_test
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +14,7 @@ import (
 	"github.com/labstack/echo/v4"
 	"github.com/ricky2122/go-echo-example/controller"
 	"github.com/ricky2122/go-echo-example/domain"
-	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
 	"github.com/ricky2122/go-echo-example/usecase"
 	"github.com/stretchr/testify/assert"
 )
@@ -22,16 +23,25 @@ type TestStubAuthUseCase struct {
 	userStore []domain.User
 }
 
-func (s *TestStubAuthUseCase) Login(input usecase.LoginUseCaseInput) error {
+func (s *TestStubAuthUseCase) Login(_ context.Context, input usecase.LoginUseCaseInput) (domain.UserID, error) {
 	for _, user := range s.userStore {
 		if input.Name == user.GetName() && input.Password == user.GetPassword() {
-			return nil
+			return user.GetID(), nil
 		}
 	}
-	return usecase.ErrLoginFailed
+	return 0, usecase.ErrLoginFailed
 }
 
-var testSessionUserID = "test01"
+func (s *TestStubAuthUseCase) GetLoginUser(_ context.Context, input usecase.GetLoginUserUseCaseInput) (*usecase.GetLoginUserUseCaseOutput, error) {
+	for _, user := range s.userStore {
+		if input.ID == user.GetID().Int() {
+			return &usecase.GetLoginUserUseCaseOutput{ID: user.GetID().Int(), Name: user.GetName()}, nil
+		}
+	}
+	return nil, usecase.ErrUserNotFound
+}
+
+var testSessionID = "test-session-id"
 
 type TestStubSessionStore struct {
 	sessionsStore map[string]*sessions.Session
@@ -86,24 +96,23 @@ func (s *TestStubSessionStore) Save(r *http.Request, w http.ResponseWriter, sess
 	}
 
 	if session.ID == "" {
-		session.ID = session.Name()
+		session.ID = testSessionID
 	}
 
-	// Check if session_id is actually present
-	value, ok := session.Values[controller.SessionKey]
+	// Check if user_id is actually present
+	value, ok := session.Values[controller.SessionUserIDKey]
 	if !ok {
-		return fmt.Errorf("session_id not found in session value")
+		return fmt.Errorf("user_id not found in session value")
 	}
 
 	// Ensure the type assertion will not panic
-	strValue, ok := value.(string)
-	if !ok {
-		return fmt.Errorf("session_id value cannot be asserted as string")
+	if _, ok := value.(int); !ok {
+		return fmt.Errorf("user_id value cannot be asserted as int")
 	}
 
 	s.sessionsStore[session.ID] = session
 
-	cookie.Value = strValue
+	cookie.Value = session.ID
 
 	http.SetCookie(w, cookie)
 
@@ -129,7 +138,7 @@ func TestLogin(t *testing.T) {
 		e.Use(session.Middleware(store))
 
 		// Set the validator
-		e.Validator = api.NewCustomValidator()
+		e.Validator = validator.NewCustomValidator()
 
 		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(loginReq))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
@@ -138,13 +147,14 @@ func TestLogin(t *testing.T) {
 		c := e.NewContext(req, rec)
 		c.Set("_session_store", store)
 
-		user := domain.NewUser(
+		user := domain.ReconstructUser(
+			1,
 			"test01",
 			"test01",
 			"test01@test.com",
 			time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
 		)
-		user.SetID(1)
 		userStore := []domain.User{user}
 		ac := controller.NewAuthController(&TestStubAuthUseCase{userStore: userStore})
 
@@ -154,8 +164,46 @@ func TestLogin(t *testing.T) {
 			// Check if the session cookie is correctly set
 			cookie := rec.Header().Get("Set-Cookie")
 			// Verify the session ID value within the cookie
-			targetCookie := fmt.Sprintf("%s=%s", controller.SessionKey, testSessionUserID)
+			targetCookie := fmt.Sprintf("%s=%s", controller.SessionKey, testSessionID)
 			assert.Contains(t, cookie, targetCookie)
+			// Verify the user ID is stored in the session instead of the user name
+			if sess, ok := store.sessionsStore[testSessionID]; assert.True(t, ok) {
+				assert.Equal(t, 1, sess.Values[controller.SessionUserIDKey])
+				assert.Contains(t, sess.Values, controller.SessionIssuedAtKey)
+				assert.Contains(t, sess.Values, controller.SessionAuthTimeKey)
+			}
+		}
+	})
+
+	t.Run("Session is renewed", func(t *testing.T) {
+		e := echo.New()
+		store := &TestStubSessionStore{
+			sessionsStore: map[string]*sessions.Session{},
+		}
+		e.Use(session.Middleware(store))
+		e.Validator = validator.NewCustomValidator()
+
+		// a session ID planted by someone else before the login
+		plantedSessionID := "planted-session-id"
+		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(loginReq))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(echo.HeaderCookie, fmt.Sprintf("%s=%s", controller.SessionKey, plantedSessionID))
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.Set("_session_store", store)
+
+		user := domain.ReconstructUser(1, "test01", "test01", "test01@test.com", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+		ac := controller.NewAuthController(&TestStubAuthUseCase{userStore: []domain.User{user}})
+
+		if assert.NoError(t, ac.Login(c)) {
+			assert.NotContains(t, store.sessionsStore, plantedSessionID)
+			assert.Contains(t, store.sessionsStore, testSessionID)
+			// the last cookie, which the browser keeps, is the new session
+			cookies := rec.Result().Cookies()
+			if assert.NotEmpty(t, cookies) {
+				assert.Equal(t, testSessionID, cookies[len(cookies)-1].Value)
+			}
 		}
 	})
 
@@ -163,7 +211,7 @@ func TestLogin(t *testing.T) {
 		loginFailedMsg := "failed login"
 		// Setup
 		e := echo.New()
-		e.Validator = api.NewCustomValidator()
+		e.Validator = validator.NewCustomValidator()
 		req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(loginReq))
 		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
 		rec := httptest.NewRecorder()
@@ -172,13 +220,9 @@ func TestLogin(t *testing.T) {
 
 		// Assertions
 		err := ac.Login(c)
-		if assert.NotNil(t, err) {
-			err, res := err.(*echo.HTTPError)
-			if res {
-				assert.Equal(t, http.StatusUnauthorized, err.Code)
-				assert.Equal(t, loginFailedMsg, err.Message)
-			}
-		}
+		problem := assertProblem(t, c, err, http.StatusUnauthorized)
+		assert.Equal(t, controller.CodeLoginFailed, problem.Code)
+		assert.Equal(t, loginFailedMsg, problem.Detail)
 	})
 }
 
@@ -194,13 +238,13 @@ func TestLogout(t *testing.T) {
 		e.Use(session.Middleware(store))
 
 		req := httptest.NewRequest(http.MethodPost, "/logout", nil)
-		cookie := fmt.Sprintf("%s=%s", controller.SessionKey, testSessionUserID)
+		cookie := fmt.Sprintf("%s=%s", controller.SessionKey, testSessionID)
 		req.Header.Set(echo.HeaderCookie, cookie)
 
 		rec := httptest.NewRecorder()
 
 		sess, _ := store.New(req, controller.SessionKey)
-		sess.Values[controller.SessionKey] = testSessionUserID
+		sess.Values[controller.SessionUserIDKey] = 1
 		sess.Options = StubDefaultOpts
 		_ = store.Save(req, rec, sess)
 
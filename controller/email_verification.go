@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+type VerifyEmailRequest struct {
+	Token string `query:"token" validate:"required"`
+}
+
+type VerifyEmailResponse struct {
+	Message string `json:"message"`
+}
+
+type ResendVerificationEmailRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+func (uc *UserController) VerifyEmail(c echo.Context) error {
+	// parse request
+	req := new(VerifyEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// verify email usecase
+	input := usecase.VerifyEmailUseCaseInput{Token: req.Token}
+	if err := uc.uuc.VerifyEmail(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response (the link is opened in a browser)
+	return c.JSONPretty(http.StatusOK, VerifyEmailResponse{Message: "email verified"}, "  ")
+}
+
+func (uc *UserController) ResendVerificationEmail(c echo.Context) error {
+	// parse request
+	req := new(ResendVerificationEmailRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// resend verification email usecase
+	input := usecase.ResendVerificationEmailUseCaseInput{Email: req.Email}
+	if err := uc.uuc.ResendVerificationEmail(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response (the same whether the email is registered or not)
+	return c.NoContent(http.StatusAccepted)
+}
@@ -0,0 +1,85 @@
+package controller_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		target   string
+		err      error
+		wantCode int
+	}{
+		{name: "StatusOK", target: "/verify-email?token=token", wantCode: http.StatusOK},
+		{name: "missing token", target: "/verify-email", wantCode: http.StatusBadRequest},
+		{name: "invalid token", target: "/verify-email?token=token", err: usecase.ErrInvalidToken, wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Assertions
+			err := uc.VerifyEmail(c)
+			if tt.wantCode == http.StatusOK {
+				if assert.NoError(t, err) {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestResendVerificationEmail(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "StatusAccepted", wantCode: http.StatusAccepted},
+		{name: "too many requests", err: usecase.ErrTooManyRequests, wantCode: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+			req := httptest.NewRequest(http.MethodPost, "/verify-email/resend", strings.NewReader(`{"email": "test01@test.com"}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			// Assertions
+			err := uc.ResendVerificationEmail(c)
+			if tt.err == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantCode, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
@@ -0,0 +1,269 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+// MIMEApplicationProblemJSON is the media type of RFC 7807 problem details.
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// ErrorCode is a stable, machine-readable identifier of an error, returned as the "code" member of a problem.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest     ErrorCode = "invalid_request"
+	CodeValidationFailed   ErrorCode = "validation_failed"
+	CodeUnauthorized       ErrorCode = "unauthorized"
+	CodeForbidden          ErrorCode = "forbidden"
+	CodeNotFound           ErrorCode = "not_found"
+	CodeMethodNotAllowed   ErrorCode = "method_not_allowed"
+	CodeTooManyRequests    ErrorCode = "too_many_requests"
+	CodeRateLimited        ErrorCode = "rate_limited"
+	CodeRequestTimeout     ErrorCode = "request_timeout"
+	CodeUnavailable        ErrorCode = "service_unavailable"
+	CodeInternal           ErrorCode = "internal_error"
+	CodeLoginFailed        ErrorCode = "login_failed"
+	CodeLoginLocked        ErrorCode = "login_locked"
+	CodeEmailNotVerified   ErrorCode = "email_not_verified"
+	CodeUserDisabled       ErrorCode = "user_disabled"
+	CodeUserAlreadyExists  ErrorCode = "user_already_exists"
+	CodeUserNotFound       ErrorCode = "user_not_found"
+	CodeEmailAlreadyExists ErrorCode = "email_already_exists"
+	CodePasswordMismatch   ErrorCode = "password_mismatch"
+	CodeInvalidToken       ErrorCode = "invalid_token"
+	CodeInvalidAccessToken ErrorCode = "invalid_access_token"
+	CodeUnsupportedGrant   ErrorCode = "unsupported_grant_type"
+	CodeSessionNotFound    ErrorCode = "session_not_found"
+	CodeInvalidCursor      ErrorCode = "invalid_cursor"
+	CodeInvalidSortKey     ErrorCode = "invalid_sort_key"
+	CodeInvalidUserName    ErrorCode = "invalid_user_name"
+	CodeInvalidEmail       ErrorCode = "invalid_email"
+	CodeInvalidBirthDay    ErrorCode = "invalid_birth_day"
+	CodeUserTooYoung       ErrorCode = "user_too_young"
+	CodeInvalidPassword    ErrorCode = "invalid_password"
+	CodeUnknownRole        ErrorCode = "unknown_role"
+)
+
+// errorMappings maps the use case and domain errors to responses,
+// so that handlers can return them as they are.
+var errorMappings = []struct {
+	err    error
+	status int
+	code   ErrorCode
+}{
+	{usecase.ErrLoginFailed, http.StatusUnauthorized, CodeLoginFailed},
+	{usecase.ErrLoginLocked, http.StatusTooManyRequests, CodeLoginLocked},
+	{usecase.ErrEmailNotVerified, http.StatusForbidden, CodeEmailNotVerified},
+	{usecase.ErrUserDisabled, http.StatusForbidden, CodeUserDisabled},
+	{usecase.ErrForbidden, http.StatusForbidden, CodeForbidden},
+	{usecase.ErrUserAlreadyExists, http.StatusConflict, CodeUserAlreadyExists},
+	{usecase.ErrEmailAlreadyExists, http.StatusConflict, CodeEmailAlreadyExists},
+	{usecase.ErrUserNotFound, http.StatusNotFound, CodeUserNotFound},
+	{usecase.ErrPasswordMismatch, http.StatusBadRequest, CodePasswordMismatch},
+	{usecase.ErrInvalidToken, http.StatusBadRequest, CodeInvalidToken},
+	{usecase.ErrInvalidAccessToken, http.StatusUnauthorized, CodeInvalidAccessToken},
+	{usecase.ErrUnsupportedGrantType, http.StatusBadRequest, CodeUnsupportedGrant},
+	{usecase.ErrSessionNotFound, http.StatusNotFound, CodeSessionNotFound},
+	{usecase.ErrInvalidCursor, http.StatusBadRequest, CodeInvalidCursor},
+	{usecase.ErrInvalidSortKey, http.StatusBadRequest, CodeInvalidSortKey},
+	{usecase.ErrTooManyRequests, http.StatusTooManyRequests, CodeTooManyRequests},
+	{domain.ErrInvalidUserName, http.StatusBadRequest, CodeInvalidUserName},
+	{domain.ErrInvalidEmail, http.StatusBadRequest, CodeInvalidEmail},
+	{domain.ErrInvalidBirthDay, http.StatusBadRequest, CodeInvalidBirthDay},
+	{domain.ErrUserTooYoung, http.StatusBadRequest, CodeUserTooYoung},
+	{domain.ErrInvalidPassword, http.StatusBadRequest, CodeInvalidPassword},
+	{domain.ErrUnknownRole, http.StatusBadRequest, CodeUnknownRole},
+}
+
+// HTTPError is an error with the response to send for it.
+type HTTPError struct {
+	Status   int
+	Code     ErrorCode
+	Detail   string
+	Fields   []FieldError
+	Internal error
+}
+
+// FieldError describes the rule a request field has failed.
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// TranslatableError is a validation error whose messages depend on the language of the request.
+type TranslatableError interface {
+	error
+	FieldErrors(acceptLanguage string) []FieldError
+}
+
+// RetryAfterError is an error telling the client when to retry, which is sent as the Retry-After header.
+type RetryAfterError interface {
+	error
+	RetryAfter() time.Duration
+}
+
+func NewHTTPError(status int, code ErrorCode, detail string) *HTTPError {
+	return &HTTPError{Status: status, Code: code, Detail: detail}
+}
+
+// NewValidationError reports the invalid fields of a request.
+func NewValidationError(fields []FieldError) *HTTPError {
+	return &HTTPError{
+		Status: http.StatusBadRequest,
+		Code:   CodeValidationFailed,
+		Detail: "request validation failed",
+		Fields: fields,
+	}
+}
+
+func (e *HTTPError) Error() string {
+	if e.Internal != nil {
+		return fmt.Sprintf("status=%d, code=%s, detail=%s, internal=%v", e.Status, e.Code, e.Detail, e.Internal)
+	}
+	return fmt.Sprintf("status=%d, code=%s, detail=%s", e.Status, e.Code, e.Detail)
+}
+
+func (e *HTTPError) Unwrap() error {
+	return e.Internal
+}
+
+// WithInternal returns a copy of the error wrapping the cause, which is logged but not sent.
+func (e *HTTPError) WithInternal(err error) *HTTPError {
+	copied := *e
+	copied.Internal = err
+	return &copied
+}
+
+var errUnauthorized = NewHTTPError(http.StatusUnauthorized, CodeUnauthorized, "unauthorized")
+
+func invalidRequest(err error) *HTTPError {
+	return NewHTTPError(http.StatusBadRequest, CodeInvalidRequest, "invalid request").WithInternal(err)
+}
+
+// Problem is an RFC 7807 problem details object.
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      ErrorCode    `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// HTTPErrorHandler sends every error returned by handlers and middlewares as application/problem+json.
+func HTTPErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	he := toHTTPError(c, err)
+	if he.Status >= http.StatusInternalServerError {
+		c.Logger().Error(err)
+	}
+
+	problem := Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(he.Status),
+		Status:    he.Status,
+		Detail:    he.Detail,
+		Instance:  c.Request().URL.Path,
+		Code:      he.Code,
+		RequestID: c.Response().Header().Get(echo.HeaderXRequestID),
+		Errors:    he.Fields,
+	}
+
+	var re RetryAfterError
+	if errors.As(err, &re) {
+		c.Response().Header().Set(echo.HeaderRetryAfter, retryAfterSeconds(re.RetryAfter()))
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(he.Status)
+	} else {
+		var body []byte
+		body, err = json.MarshalIndent(problem, "", "  ")
+		if err == nil {
+			err = c.Blob(he.Status, MIMEApplicationProblemJSON, body)
+		}
+	}
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+func toHTTPError(c echo.Context, err error) *HTTPError {
+	var he *HTTPError
+	if errors.As(err, &he) {
+		return he
+	}
+
+	var te TranslatableError
+	if errors.As(err, &te) {
+		return NewValidationError(te.FieldErrors(c.Request().Header.Get("Accept-Language"))).WithInternal(err)
+	}
+
+	for _, m := range errorMappings {
+		if errors.Is(err, m.err) {
+			return NewHTTPError(m.status, m.code, m.err.Error()).WithInternal(err)
+		}
+	}
+
+	// an error caused by the request deadline is reported as 503 so that the client can retry
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(c.Request().Context().Err(), context.DeadlineExceeded) {
+		return NewHTTPError(http.StatusServiceUnavailable, CodeRequestTimeout, "request timeout").WithInternal(err)
+	}
+
+	// errors of echo itself, e.g. unknown routes
+	var ee *echo.HTTPError
+	if errors.As(err, &ee) {
+		detail := http.StatusText(ee.Code)
+		if message, ok := ee.Message.(string); ok {
+			detail = message
+		}
+		return NewHTTPError(ee.Code, codeForStatus(ee.Code), detail).WithInternal(err)
+	}
+
+	return NewHTTPError(http.StatusInternalServerError, CodeInternal, "internal server error").WithInternal(err)
+}
+
+// retryAfterSeconds rounds the wait up to whole seconds, so that the client does not retry too early.
+func retryAfterSeconds(wait time.Duration) string {
+	seconds := int64((wait + time.Second - 1) / time.Second)
+	return strconv.FormatInt(max(seconds, 1), 10)
+}
+
+func codeForStatus(status int) ErrorCode {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodeForbidden
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusMethodNotAllowed:
+		return CodeMethodNotAllowed
+	case http.StatusTooManyRequests:
+		return CodeTooManyRequests
+	case http.StatusServiceUnavailable:
+		return CodeRequestTimeout
+	}
+	if status >= http.StatusInternalServerError {
+		return CodeInternal
+	}
+	return CodeInvalidRequest
+}
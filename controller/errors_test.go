@@ -0,0 +1,209 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// assertProblem sends err through the error handler and checks the status of the problem response.
+func assertProblem(t *testing.T, c echo.Context, err error, wantStatus int) controller.Problem {
+	t.Helper()
+	var problem controller.Problem
+	if !assert.Error(t, err) {
+		return problem
+	}
+
+	controller.HTTPErrorHandler(err, c)
+	rec := c.Response().Writer.(*httptest.ResponseRecorder)
+	assert.Equal(t, wantStatus, rec.Code)
+	assert.Equal(t, controller.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+	if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &problem)) {
+		assert.Equal(t, wantStatus, problem.Status)
+	}
+	return problem
+}
+
+func TestHTTPErrorHandler(t *testing.T) {
+	type request struct {
+		Name string `json:"name" validate:"required"`
+	}
+
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+	e.HTTPErrorHandler = controller.HTTPErrorHandler
+	e.Use(middleware.RequestIDWithConfig(middleware.RequestIDConfig{
+		Generator: func() string { return "request-id" },
+	}))
+	e.GET("/users/1", func(c echo.Context) error {
+		return usecase.ErrUserNotFound
+	})
+	e.POST("/users", func(c echo.Context) error {
+		return c.Validate(&request{})
+	})
+	e.GET("/error", func(c echo.Context) error {
+		return errors.New("secret")
+	})
+	e.GET("/timeout", func(c echo.Context) error {
+		return context.DeadlineExceeded
+	})
+
+	cases := []struct {
+		name       string
+		method     string
+		target     string
+		wantStatus int
+		want       controller.Problem
+	}{
+		{
+			name:       "use case error",
+			method:     http.MethodGet,
+			target:     "/users/1",
+			wantStatus: http.StatusNotFound,
+			want: controller.Problem{
+				Type:      "about:blank",
+				Title:     "Not Found",
+				Status:    http.StatusNotFound,
+				Detail:    "user not found",
+				Instance:  "/users/1",
+				Code:      controller.CodeUserNotFound,
+				RequestID: "request-id",
+			},
+		},
+		{
+			name:       "validation error",
+			method:     http.MethodPost,
+			target:     "/users",
+			wantStatus: http.StatusBadRequest,
+			want: controller.Problem{
+				Type:      "about:blank",
+				Title:     "Bad Request",
+				Status:    http.StatusBadRequest,
+				Detail:    "request validation failed",
+				Instance:  "/users",
+				Code:      controller.CodeValidationFailed,
+				RequestID: "request-id",
+				Errors: []controller.FieldError{{
+					Field:   "name",
+					Rule:    "required",
+					Message: "name is a required field",
+				}},
+			},
+		},
+		{
+			name:       "unknown route",
+			method:     http.MethodGet,
+			target:     "/unknown",
+			wantStatus: http.StatusNotFound,
+			want: controller.Problem{
+				Type:      "about:blank",
+				Title:     "Not Found",
+				Status:    http.StatusNotFound,
+				Detail:    "Not Found",
+				Instance:  "/unknown",
+				Code:      controller.CodeNotFound,
+				RequestID: "request-id",
+			},
+		},
+		{
+			name:       "unexpected error is hidden",
+			method:     http.MethodGet,
+			target:     "/error",
+			wantStatus: http.StatusInternalServerError,
+			want: controller.Problem{
+				Type:      "about:blank",
+				Title:     "Internal Server Error",
+				Status:    http.StatusInternalServerError,
+				Detail:    "internal server error",
+				Instance:  "/error",
+				Code:      controller.CodeInternal,
+				RequestID: "request-id",
+			},
+		},
+		{
+			name:       "timeout",
+			method:     http.MethodGet,
+			target:     "/timeout",
+			wantStatus: http.StatusServiceUnavailable,
+			want: controller.Problem{
+				Type:      "about:blank",
+				Title:     "Service Unavailable",
+				Status:    http.StatusServiceUnavailable,
+				Detail:    "request timeout",
+				Instance:  "/timeout",
+				Code:      controller.CodeRequestTimeout,
+				RequestID: "request-id",
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{}`))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			// Assertions
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Equal(t, controller.MIMEApplicationProblemJSON, rec.Header().Get(echo.HeaderContentType))
+			var got controller.Problem
+			if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got)) {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestHTTPErrorHandlerRetryAfter(t *testing.T) {
+	cases := []struct {
+		name           string
+		err            error
+		wantCode       controller.ErrorCode
+		wantRetryAfter string
+	}{
+		{
+			name:           "login locked",
+			err:            &usecase.LoginThrottledError{Wait: 15 * time.Minute, Locked: true},
+			wantCode:       controller.CodeLoginLocked,
+			wantRetryAfter: "900",
+		},
+		{
+			name:           "login delayed, rounded up",
+			err:            &usecase.LoginThrottledError{Wait: 1500 * time.Millisecond},
+			wantCode:       controller.CodeTooManyRequests,
+			wantRetryAfter: "2",
+		},
+		{
+			name:     "without retry after",
+			err:      usecase.ErrTooManyRequests,
+			wantCode: controller.CodeTooManyRequests,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPost, "/login", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+
+			problem := assertProblem(t, c, tt.err, http.StatusTooManyRequests)
+			assert.Equal(t, tt.wantCode, problem.Code)
+			assert.Equal(t, tt.wantRetryAfter, rec.Header().Get(echo.HeaderRetryAfter))
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+)
+
+// Pinger is a dependency the server cannot serve requests without, e.g. the database.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+type HealthResponse struct {
+	Status string `json:"status"`
+}
+
+type HealthController struct {
+	db Pinger
+}
+
+func NewHealthController(db Pinger) *HealthController {
+	return &HealthController{db: db}
+}
+
+// Healthz reports 200 when the server and its database are up, for load balancers and container health checks.
+func (hc *HealthController) Healthz(c echo.Context) error {
+	if err := hc.db.PingContext(c.Request().Context()); err != nil {
+		return NewHTTPError(http.StatusServiceUnavailable, CodeUnavailable, "database unavailable").WithInternal(err)
+	}
+	return c.JSONPretty(http.StatusOK, HealthResponse{Status: "ok"}, "  ")
+}
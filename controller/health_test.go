@@ -0,0 +1,44 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubPinger struct {
+	err error
+}
+
+func (s *TestStubPinger) PingContext(context.Context) error {
+	return s.err
+}
+
+func TestHealthz(t *testing.T) {
+	e := echo.New()
+
+	t.Run("StatusOK", func(t *testing.T) {
+		hc := controller.NewHealthController(&TestStubPinger{})
+		rec := httptest.NewRecorder()
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/healthz", nil), rec)
+
+		if assert.NoError(t, hc.Healthz(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+		}
+	})
+
+	t.Run("database unavailable", func(t *testing.T) {
+		hc := controller.NewHealthController(&TestStubPinger{err: errors.New("connection refused")})
+		c := e.NewContext(httptest.NewRequest(http.MethodGet, "/healthz", nil), httptest.NewRecorder())
+
+		problem := assertProblem(t, c, hc.Healthz(c), http.StatusServiceUnavailable)
+		assert.Equal(t, controller.CodeUnavailable, problem.Code)
+	})
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/labstack/echo-contrib/session"
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+const loginUserKey = "login_user"
+
+type LoginUser struct {
+	ID   int
+	Name string
+}
+
+// GetLoginUser returns the user stored by AuthMiddleware.RequireLogin.
+func GetLoginUser(c echo.Context) (LoginUser, bool) {
+	loginUser, ok := c.Get(loginUserKey).(LoginUser)
+	return loginUser, ok
+}
+
+func SetLoginUser(c echo.Context, loginUser LoginUser) {
+	c.Set(loginUserKey, loginUser)
+}
+
+// errInvalidBearerToken is returned for an invalid or expired access token, which the client can refresh.
+var errInvalidBearerToken = NewHTTPError(http.StatusUnauthorized, CodeInvalidAccessToken, "invalid or expired access token")
+
+type AuthMiddleware struct {
+	au IAuthUseCase
+	// tu is nil when bearer tokens are disabled
+	tu ITokenUseCase
+}
+
+func NewAuthMiddleware(au IAuthUseCase, tu ITokenUseCase) AuthMiddleware {
+	return AuthMiddleware{au: au, tu: tu}
+}
+
+// RequireLogin resolves the logged-in user from the "Authorization: Bearer" access token or else from the session,
+// and rejects the request with 401 if there is none.
+func (am *AuthMiddleware) RequireLogin(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		// get user id from the access token or the session
+		var userID int
+		if accessToken, ok := bearerToken(c.Request()); ok {
+			id, err := am.verifyAccessToken(c, accessToken)
+			if err != nil {
+				return err
+			}
+			userID = id
+		} else {
+			sess, err := session.Get(SessionKey, c)
+			if err != nil {
+				return err
+			}
+			id, ok := sess.Values[SessionUserIDKey].(int)
+			if !ok {
+				return errUnauthorized
+			}
+			userID = id
+		}
+
+		// get login user usecase
+		input := usecase.GetLoginUserUseCaseInput{ID: userID}
+		output, err := am.au.GetLoginUser(c.Request().Context(), input)
+		if err != nil {
+			if errors.Is(err, usecase.ErrUserNotFound) {
+				return errUnauthorized
+			}
+			return err
+		}
+
+		SetLoginUser(c, LoginUser{ID: output.ID, Name: output.Name})
+
+		return next(c)
+	}
+}
+
+func (am *AuthMiddleware) verifyAccessToken(c echo.Context, accessToken string) (int, error) {
+	// tell the client to refresh or get a new token (RFC 6750)
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, `Bearer error="invalid_token"`)
+	if am.tu == nil {
+		return 0, errInvalidBearerToken
+	}
+
+	input := usecase.VerifyAccessTokenUseCaseInput{AccessToken: accessToken}
+	output, err := am.tu.VerifyAccessToken(c.Request().Context(), input)
+	if err != nil {
+		if errors.Is(err, usecase.ErrInvalidAccessToken) {
+			return 0, errInvalidBearerToken.WithInternal(err)
+		}
+		return 0, err
+	}
+
+	c.Response().Header().Del(echo.HeaderWWWAuthenticate)
+	return output.UserID, nil
+}
+
+// bearerToken returns the token of the "Authorization: Bearer <token>" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get(echo.HeaderAuthorization), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
+
+type AuthorizationMiddleware struct {
+	ru IRoleUseCase
+}
+
+func NewAuthorizationMiddleware(ru IRoleUseCase) AuthorizationMiddleware {
+	return AuthorizationMiddleware{ru: ru}
+}
+
+// RequirePermission rejects the request with 403 unless a role of the login user grants the permission.
+// It has to run after AuthMiddleware.RequireLogin.
+func (rm *AuthorizationMiddleware) RequirePermission(permission domain.Permission) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			loginUser, ok := GetLoginUser(c)
+			if !ok {
+				return errUnauthorized
+			}
+
+			input := usecase.AuthorizeUseCaseInput{UserID: loginUser.ID, Permission: permission}
+			if err := rm.ru.Authorize(c.Request().Context(), input); err != nil {
+				return err
+			}
+
+			return next(c)
+		}
+	}
+}
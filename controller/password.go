@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type RequestPasswordResetRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ConfirmPasswordResetRequest struct {
+	Token       string `json:"token" validate:"required"`
+	NewPassword string `json:"new_password" validate:"required,min=8,max=72"`
+}
+
+type IPasswordUseCase interface {
+	ChangePassword(ctx context.Context, input usecase.ChangePasswordUseCaseInput) error
+	RequestPasswordReset(ctx context.Context, input usecase.RequestPasswordResetUseCaseInput) error
+	ConfirmPasswordReset(ctx context.Context, input usecase.ConfirmPasswordResetUseCaseInput) error
+}
+
+type PasswordController struct {
+	pu IPasswordUseCase
+}
+
+func NewPasswordController(pu IPasswordUseCase) PasswordController {
+	return PasswordController{pu: pu}
+}
+
+func (pc *PasswordController) ChangePassword(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// parse request
+	req := new(ChangePasswordRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// change password usecase
+	input := usecase.ChangePasswordUseCaseInput{
+		UserID:          loginUser.ID,
+		CurrentPassword: req.CurrentPassword,
+		NewPassword:     req.NewPassword,
+	}
+	if err := pc.pu.ChangePassword(c.Request().Context(), input); err != nil {
+		// the login user has been deleted since the session was checked
+		if errors.Is(err, usecase.ErrUserNotFound) {
+			return errUnauthorized.WithInternal(err)
+		}
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (pc *PasswordController) RequestPasswordReset(c echo.Context) error {
+	// parse request
+	req := new(RequestPasswordResetRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// request password reset usecase
+	input := usecase.RequestPasswordResetUseCaseInput{Email: req.Email}
+	if err := pc.pu.RequestPasswordReset(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response (the same whether the email is registered or not)
+	return c.NoContent(http.StatusAccepted)
+}
+
+func (pc *PasswordController) ConfirmPasswordReset(c echo.Context) error {
+	// parse request
+	req := new(ConfirmPasswordResetRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// confirm password reset usecase
+	input := usecase.ConfirmPasswordResetUseCaseInput{Token: req.Token, NewPassword: req.NewPassword}
+	if err := pc.pu.ConfirmPasswordReset(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,134 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubPasswordUseCase struct {
+	changePasswordInput usecase.ChangePasswordUseCaseInput
+	err                 error
+}
+
+func (s *TestStubPasswordUseCase) ChangePassword(_ context.Context, input usecase.ChangePasswordUseCaseInput) error {
+	s.changePasswordInput = input
+	return s.err
+}
+
+func (s *TestStubPasswordUseCase) RequestPasswordReset(_ context.Context, _ usecase.RequestPasswordResetUseCaseInput) error {
+	return s.err
+}
+
+func (s *TestStubPasswordUseCase) ConfirmPasswordReset(_ context.Context, _ usecase.ConfirmPasswordResetUseCaseInput) error {
+	return s.err
+}
+
+func newPasswordContext(e *echo.Echo, path, reqJSON string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(reqJSON))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	return e.NewContext(req, rec), rec
+}
+
+func TestChangePassword(t *testing.T) {
+	changePasswordReq := `{
+		"current_password": "test01",
+		"new_password": "new-password"
+	  }
+	  `
+
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	t.Run("StatusNoContent", func(t *testing.T) {
+		stub := &TestStubPasswordUseCase{}
+		pc := controller.NewPasswordController(stub)
+		c, rec := newPasswordContext(e, "/users/me/password", changePasswordReq)
+		controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+		// Assertions
+		if assert.NoError(t, pc.ChangePassword(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Equal(t, 1, stub.changePasswordInput.UserID)
+			assert.Equal(t, "new-password", stub.changePasswordInput.NewPassword)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			reqJSON  string
+			err      error
+			wantCode int
+		}{
+			{name: "short password", reqJSON: `{"current_password": "test01", "new_password": "short"}`, wantCode: http.StatusBadRequest},
+			{name: "password mismatch", reqJSON: changePasswordReq, err: usecase.ErrPasswordMismatch, wantCode: http.StatusBadRequest},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				pc := controller.NewPasswordController(&TestStubPasswordUseCase{err: tt.err})
+				c, _ := newPasswordContext(e, "/users/me/password", tt.reqJSON)
+				controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+				// Assertions
+				err := pc.ChangePassword(c)
+				assertProblem(t, c, err, tt.wantCode)
+			})
+		}
+	})
+}
+
+func TestRequestPasswordReset(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+	pc := controller.NewPasswordController(&TestStubPasswordUseCase{})
+	c, rec := newPasswordContext(e, "/password-reset", `{"email": "test01@test.com"}`)
+
+	// Assertions
+	if assert.NoError(t, pc.RequestPasswordReset(c)) {
+		assert.Equal(t, http.StatusAccepted, rec.Code)
+	}
+}
+
+func TestConfirmPasswordReset(t *testing.T) {
+	confirmReq := `{
+		"token": "token",
+		"new_password": "new-password"
+	  }
+	  `
+
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	t.Run("StatusNoContent", func(t *testing.T) {
+		pc := controller.NewPasswordController(&TestStubPasswordUseCase{})
+		c, rec := newPasswordContext(e, "/password-reset/confirm", confirmReq)
+
+		// Assertions
+		if assert.NoError(t, pc.ConfirmPasswordReset(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+		}
+	})
+
+	t.Run("invalid token", func(t *testing.T) {
+		pc := controller.NewPasswordController(&TestStubPasswordUseCase{err: usecase.ErrInvalidToken})
+		c, _ := newPasswordContext(e, "/password-reset/confirm", confirmReq)
+
+		// Assertions
+		err := pc.ConfirmPasswordReset(c)
+		assertProblem(t, c, err, http.StatusBadRequest)
+	})
+}
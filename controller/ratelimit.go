@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+	// HeaderAPIKey identifies the client of NewRateLimitByAPIKey
+	HeaderAPIKey = "X-API-Key"
+)
+
+// RateLimitResult is the outcome of taking one request from a rate limit.
+type RateLimitResult struct {
+	Allowed   bool
+	Remaining int
+	// Reset is how long until the whole limit is available again
+	Reset time.Duration
+	// RetryAfter is how long until the next request is allowed, if this one is not
+	RetryAfter time.Duration
+}
+
+// RateLimitStore counts the requests by key, allowing limit requests per window.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, limit int, window time.Duration) (RateLimitResult, error)
+}
+
+// RateLimitKeyFunc returns the key the requests are counted by.
+type RateLimitKeyFunc func(c echo.Context) string
+
+// RateLimitPolicy allows Limit requests per Window for each key of a route group; a zero Limit disables it.
+type RateLimitPolicy struct {
+	// Name separates the counts of the policies
+	Name   string
+	Limit  int
+	Window time.Duration
+	// Key defaults to RateLimitByIP
+	Key RateLimitKeyFunc
+}
+
+// RateLimitByIP counts the requests by client IP.
+func RateLimitByIP(c echo.Context) string {
+	return "ip:" + c.RealIP()
+}
+
+// RateLimitByUser counts the requests by the user set by AuthMiddleware.RequireLogin,
+// and by client IP if there is none.
+func RateLimitByUser(c echo.Context) string {
+	if loginUser, ok := GetLoginUser(c); ok {
+		return "user:" + strconv.Itoa(loginUser.ID)
+	}
+	return RateLimitByIP(c)
+}
+
+// NewRateLimitByAPIKey counts the requests of each of the API keys by the X-API-Key header,
+// and the other requests by client IP, so that a client cannot get a new limit by making up a key.
+// The keys are hashed so that they are not stored.
+func NewRateLimitByAPIKey(apiKeys []string) RateLimitKeyFunc {
+	known := make(map[string]bool, len(apiKeys))
+	for _, apiKey := range apiKeys {
+		known[hashAPIKey(apiKey)] = true
+	}
+	return func(c echo.Context) string {
+		if hash := hashAPIKey(c.Request().Header.Get(HeaderAPIKey)); known[hash] {
+			return "api_key:" + hash
+		}
+		return RateLimitByIP(c)
+	}
+}
+
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
+
+// rateLimitExceededError tells the error handler when the client may retry.
+type rateLimitExceededError struct {
+	retryAfter time.Duration
+}
+
+func (e *rateLimitExceededError) Error() string {
+	return fmt.Sprintf("rate limit exceeded, retry after %s", e.retryAfter)
+}
+
+func (e *rateLimitExceededError) RetryAfter() time.Duration {
+	return e.retryAfter
+}
+
+type RateLimitMiddleware struct {
+	store RateLimitStore
+	// failOpen lets the requests through while the store fails, instead of rejecting them with 503
+	failOpen bool
+}
+
+func NewRateLimitMiddleware(store RateLimitStore, failOpen bool) RateLimitMiddleware {
+	return RateLimitMiddleware{store: store, failOpen: failOpen}
+}
+
+// Limit rejects the requests over the policy with 429, and sends the RateLimit-* headers of the policy.
+// While the store fails, the requests are let through with the error logged if the middleware fails open,
+// and rejected with 503 otherwise.
+func (rm *RateLimitMiddleware) Limit(policy RateLimitPolicy) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if policy.Limit <= 0 {
+			return next
+		}
+		keyFunc := policy.Key
+		if keyFunc == nil {
+			keyFunc = RateLimitByIP
+		}
+		return func(c echo.Context) error {
+			key := policy.Name + ":" + keyFunc(c)
+			result, err := rm.store.Take(c.Request().Context(), key, policy.Limit, policy.Window)
+			if err != nil {
+				err = fmt.Errorf("rate limit %s: %w", policy.Name, err)
+				if !rm.failOpen {
+					return NewHTTPError(http.StatusServiceUnavailable, CodeUnavailable, "rate limit unavailable").WithInternal(err)
+				}
+				c.Logger().Error(err)
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set(HeaderRateLimitLimit, strconv.Itoa(policy.Limit))
+			header.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+			header.Set(HeaderRateLimitReset, retryAfterSeconds(result.Reset))
+			header.Set(HeaderRateLimitPolicy, fmt.Sprintf("%d;w=%d", policy.Limit, int64(policy.Window/time.Second)))
+			if !result.Allowed {
+				return NewHTTPError(http.StatusTooManyRequests, CodeRateLimited, "rate limit exceeded").
+					WithInternal(&rateLimitExceededError{retryAfter: result.RetryAfter})
+			}
+
+			return next(c)
+		}
+	}
+}
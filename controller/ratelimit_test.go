@@ -0,0 +1,138 @@
+package controller_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStubRateLimitStore allows limit requests per key, without refilling.
+type TestStubRateLimitStore struct {
+	counts map[string]int
+	keys   []string
+	err    error
+}
+
+func (s *TestStubRateLimitStore) Take(_ context.Context, key string, limit int, window time.Duration) (controller.RateLimitResult, error) {
+	if s.err != nil {
+		return controller.RateLimitResult{}, s.err
+	}
+	if s.counts == nil {
+		s.counts = map[string]int{}
+	}
+	s.keys = append(s.keys, key)
+
+	if s.counts[key] >= limit {
+		return controller.RateLimitResult{Reset: window, RetryAfter: window / time.Duration(limit)}, nil
+	}
+	s.counts[key]++
+	return controller.RateLimitResult{Allowed: true, Remaining: limit - s.counts[key], Reset: window}, nil
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	newEcho := func(store controller.RateLimitStore, policy controller.RateLimitPolicy, failOpen bool) *echo.Echo {
+		e := echo.New()
+		e.HTTPErrorHandler = controller.HTTPErrorHandler
+		rm := controller.NewRateLimitMiddleware(store, failOpen)
+		e.POST("/signup", func(c echo.Context) error {
+			return c.NoContent(http.StatusCreated)
+		}, rm.Limit(policy))
+		return e
+	}
+	signup := func(e *echo.Echo, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/signup", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("limit and headers", func(t *testing.T) {
+		store := &TestStubRateLimitStore{}
+		e := newEcho(store, controller.RateLimitPolicy{Name: "signup", Limit: 2, Window: time.Hour}, true)
+
+		for i := 0; i < 2; i++ {
+			rec := signup(e, "192.0.2.1:1234")
+			assert.Equal(t, http.StatusCreated, rec.Code)
+			assert.Equal(t, "2", rec.Header().Get(controller.HeaderRateLimitLimit))
+			assert.Equal(t, []string{"1", "0"}[i], rec.Header().Get(controller.HeaderRateLimitRemaining))
+			assert.Equal(t, "3600", rec.Header().Get(controller.HeaderRateLimitReset))
+			assert.Equal(t, "2;w=3600", rec.Header().Get(controller.HeaderRateLimitPolicy))
+		}
+
+		rec := signup(e, "192.0.2.1:1234")
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+		assert.Equal(t, "0", rec.Header().Get(controller.HeaderRateLimitRemaining))
+		assert.Equal(t, "1800", rec.Header().Get(echo.HeaderRetryAfter))
+		assert.Contains(t, rec.Body.String(), `"code": "rate_limited"`)
+
+		// another IP has its own limit
+		rec = signup(e, "192.0.2.2:1234")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, []string{"signup:ip:192.0.2.1", "signup:ip:192.0.2.1", "signup:ip:192.0.2.1", "signup:ip:192.0.2.2"}, store.keys)
+	})
+
+	t.Run("zero limit disables the policy", func(t *testing.T) {
+		store := &TestStubRateLimitStore{}
+		e := newEcho(store, controller.RateLimitPolicy{Name: "signup"}, true)
+
+		rec := signup(e, "192.0.2.1:1234")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(controller.HeaderRateLimitLimit))
+		assert.Empty(t, store.keys)
+	})
+
+	t.Run("store failure lets the request through", func(t *testing.T) {
+		store := &TestStubRateLimitStore{err: errors.New("connection refused")}
+		e := newEcho(store, controller.RateLimitPolicy{Name: "signup", Limit: 1, Window: time.Hour}, true)
+
+		rec := signup(e, "192.0.2.1:1234")
+		assert.Equal(t, http.StatusCreated, rec.Code)
+	})
+
+	t.Run("store failure rejects the request unless failing open", func(t *testing.T) {
+		store := &TestStubRateLimitStore{err: errors.New("connection refused")}
+		e := newEcho(store, controller.RateLimitPolicy{Name: "signup", Limit: 1, Window: time.Hour}, false)
+
+		rec := signup(e, "192.0.2.1:1234")
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"code": "service_unavailable"`)
+	})
+}
+
+func TestRateLimitKeys(t *testing.T) {
+	e := echo.New()
+	newContext := func(apiKey string) echo.Context {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		if apiKey != "" {
+			req.Header.Set(controller.HeaderAPIKey, apiKey)
+		}
+		return e.NewContext(req, httptest.NewRecorder())
+	}
+
+	byAPIKey := controller.NewRateLimitByAPIKey([]string{"secret"})
+
+	c := newContext("")
+	assert.Equal(t, "ip:192.0.2.1", controller.RateLimitByIP(c))
+	assert.Equal(t, "ip:192.0.2.1", controller.RateLimitByUser(c))
+	assert.Equal(t, "ip:192.0.2.1", byAPIKey(c))
+
+	controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+	assert.Equal(t, "user:1", controller.RateLimitByUser(c))
+
+	// the API key is not kept as it is
+	c = newContext("secret")
+	assert.Equal(t, "api_key:2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", byAPIKey(c))
+
+	// an unknown key does not get a limit of its own
+	c = newContext("made-up")
+	assert.Equal(t, "ip:192.0.2.1", byAPIKey(c))
+}
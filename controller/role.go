@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+type GetUserRolesRequest struct {
+	ID int `param:"id" validate:"gte=1"`
+}
+
+type GetUserRolesResponse struct {
+	Roles       []string `json:"roles"`
+	Permissions []string `json:"permissions"`
+}
+
+type UserRoleRequest struct {
+	ID   int    `param:"id" validate:"gte=1"`
+	Role string `param:"role" validate:"required"`
+}
+
+type IRoleUseCase interface {
+	Authorize(ctx context.Context, input usecase.AuthorizeUseCaseInput) error
+	GetUserRoles(ctx context.Context, input usecase.GetUserRolesUseCaseInput) (*usecase.GetUserRolesUseCaseOutput, error)
+	AssignRole(ctx context.Context, input usecase.AssignRoleUseCaseInput) error
+	RevokeRole(ctx context.Context, input usecase.RevokeRoleUseCaseInput) error
+}
+
+type RoleController struct {
+	ru IRoleUseCase
+}
+
+func NewRoleController(ru IRoleUseCase) RoleController {
+	return RoleController{ru: ru}
+}
+
+func (rc *RoleController) GetUserRoles(c echo.Context) error {
+	// parse request
+	req := new(GetUserRolesRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// get user roles usecase
+	input := usecase.GetUserRolesUseCaseInput{UserID: req.ID}
+	output, err := rc.ru.GetUserRoles(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+
+	// send response
+	res := GetUserRolesResponse{
+		Roles:       output.Roles,
+		Permissions: output.Permissions,
+	}
+	return c.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func (rc *RoleController) AssignRole(c echo.Context) error {
+	// parse request
+	req := new(UserRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// assign role usecase
+	input := usecase.AssignRoleUseCaseInput{UserID: req.ID, Role: req.Role}
+	if err := rc.ru.AssignRole(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (rc *RoleController) RevokeRole(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// parse request
+	req := new(UserRoleRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// revoke role usecase
+	input := usecase.RevokeRoleUseCaseInput{LoginUserID: loginUser.ID, UserID: req.ID, Role: req.Role}
+	if err := rc.ru.RevokeRole(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package controller_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubRoleUseCase struct {
+	// permissions granted to every user
+	permissions []domain.Permission
+	revokeInput usecase.RevokeRoleUseCaseInput
+	err         error
+}
+
+func (s *TestStubRoleUseCase) Authorize(_ context.Context, input usecase.AuthorizeUseCaseInput) error {
+	for _, permission := range s.permissions {
+		if permission == input.Permission {
+			return nil
+		}
+	}
+	return usecase.ErrForbidden
+}
+
+func (s *TestStubRoleUseCase) GetUserRoles(context.Context, usecase.GetUserRolesUseCaseInput) (*usecase.GetUserRolesUseCaseOutput, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &usecase.GetUserRolesUseCaseOutput{Roles: []string{"member"}, Permissions: []string{"users:read", "users:list"}}, nil
+}
+
+func (s *TestStubRoleUseCase) AssignRole(context.Context, usecase.AssignRoleUseCaseInput) error {
+	return s.err
+}
+
+func (s *TestStubRoleUseCase) RevokeRole(_ context.Context, input usecase.RevokeRoleUseCaseInput) error {
+	s.revokeInput = input
+	return s.err
+}
+
+func TestRequirePermission(t *testing.T) {
+	rm := controller.NewAuthorizationMiddleware(&TestStubRoleUseCase{permissions: []domain.Permission{domain.PermissionUsersList}})
+
+	cases := []struct {
+		name       string
+		loggedIn   bool
+		permission domain.Permission
+		wantCode   int
+	}{
+		{name: "granted", loggedIn: true, permission: domain.PermissionUsersList, wantCode: http.StatusOK},
+		{name: "not granted", loggedIn: true, permission: domain.PermissionRolesAssign, wantCode: http.StatusForbidden},
+		{name: "not logged in", permission: domain.PermissionUsersList, wantCode: http.StatusUnauthorized},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			rec := httptest.NewRecorder()
+			c := e.NewContext(httptest.NewRequest(http.MethodGet, "/users", nil), rec)
+			if tt.loggedIn {
+				controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+			}
+
+			h := rm.RequirePermission(tt.permission)(func(c echo.Context) error {
+				return c.NoContent(http.StatusOK)
+			})
+
+			// Assertions
+			err := h(c)
+			if tt.wantCode == http.StatusOK {
+				if assert.NoError(t, err) {
+					assert.Equal(t, http.StatusOK, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestGetUserRoles(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+		wantBody string
+	}{
+		{
+			name:     "StatusOK",
+			wantCode: http.StatusOK,
+			wantBody: `{"roles":["member"],"permissions":["users:read","users:list"]}`,
+		},
+		{name: "not found", err: usecase.ErrUserNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := controller.NewRoleController(&TestStubRoleUseCase{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/users/2/roles", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/admin/users/:id/roles")
+			c.SetParamNames("id")
+			c.SetParamValues("2")
+
+			// Assertions
+			err := rc.GetUserRoles(c)
+			if tt.err == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantCode, rec.Code)
+					assert.JSONEq(t, tt.wantBody, rec.Body.String())
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestAssignRole(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "no content", wantCode: http.StatusNoContent},
+		{name: "unknown role", err: domain.ErrUnknownRole, wantCode: http.StatusBadRequest},
+		{name: "not found", err: usecase.ErrUserNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rc := controller.NewRoleController(&TestStubRoleUseCase{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodPut, "/admin/users/2/roles/admin", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/admin/users/:id/roles/:role")
+			c.SetParamNames("id", "role")
+			c.SetParamValues("2", "admin")
+
+			// Assertions
+			err := rc.AssignRole(c)
+			if tt.err == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantCode, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestRevokeRole(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	t.Run("no content", func(t *testing.T) {
+		ru := &TestStubRoleUseCase{}
+		rc := controller.NewRoleController(ru)
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/users/2/roles/admin", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetPath("/admin/users/:id/roles/:role")
+		c.SetParamNames("id", "role")
+		c.SetParamValues("2", "admin")
+		controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+		// Assertions
+		if assert.NoError(t, rc.RevokeRole(c)) {
+			assert.Equal(t, http.StatusNoContent, rec.Code)
+			assert.Equal(t, usecase.RevokeRoleUseCaseInput{LoginUserID: 1, UserID: 2, Role: "admin"}, ru.revokeInput)
+		}
+	})
+
+	t.Run("own admin role", func(t *testing.T) {
+		rc := controller.NewRoleController(&TestStubRoleUseCase{err: usecase.ErrForbidden})
+
+		req := httptest.NewRequest(http.MethodDelete, "/admin/users/1/roles/admin", nil)
+		c := e.NewContext(req, httptest.NewRecorder())
+		c.SetPath("/admin/users/:id/roles/:role")
+		c.SetParamNames("id", "role")
+		c.SetParamValues("1", "admin")
+		controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+		assertProblem(t, c, rc.RevokeRole(c), http.StatusForbidden)
+	})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+type IssueTokenRequest struct {
+	GrantType    string `json:"grant_type" validate:"required,oneof=password refresh_token"`
+	Name         string `json:"name"`
+	Password     string `json:"password"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type RevokeTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type IssueTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type SessionResponse struct {
+	ID         string    `json:"id"`
+	Device     string    `json:"device"`
+	AuthTime   time.Time `json:"auth_time"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+type GetSessionsResponse struct {
+	Sessions []SessionResponse `json:"sessions"`
+}
+
+type ITokenUseCase interface {
+	IssueToken(ctx context.Context, input usecase.IssueTokenUseCaseInput) (*usecase.IssueTokenUseCaseOutput, error)
+	RevokeToken(ctx context.Context, input usecase.RevokeTokenUseCaseInput) error
+	GetSessions(ctx context.Context, input usecase.GetSessionsUseCaseInput) (*usecase.GetSessionsUseCaseOutput, error)
+	RevokeSession(ctx context.Context, input usecase.RevokeSessionUseCaseInput) error
+	VerifyAccessToken(ctx context.Context, input usecase.VerifyAccessTokenUseCaseInput) (*usecase.VerifyAccessTokenUseCaseOutput, error)
+}
+
+// TokenController issues bearer tokens to the clients that cannot keep the session cookie.
+type TokenController struct {
+	tu ITokenUseCase
+}
+
+func NewTokenController(tu ITokenUseCase) TokenController {
+	return TokenController{tu: tu}
+}
+
+func (tc *TokenController) IssueToken(c echo.Context) error {
+	// parse request
+	req := new(IssueTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// issue token usecase
+	input := usecase.IssueTokenUseCaseInput{
+		GrantType:    req.GrantType,
+		Name:         req.Name,
+		Password:     req.Password,
+		RefreshToken: req.RefreshToken,
+		Device:       c.Request().UserAgent(),
+		IP:           c.RealIP(),
+	}
+	output, err := tc.tu.IssueToken(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+
+	// send response, which must not be cached as it holds the tokens
+	res := IssueTokenResponse{
+		AccessToken:  output.AccessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    int(output.ExpiresIn.Seconds()),
+		RefreshToken: output.RefreshToken,
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func (tc *TokenController) RevokeToken(c echo.Context) error {
+	// parse request
+	req := new(RevokeTokenRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// revoke token usecase
+	input := usecase.RevokeTokenUseCaseInput{RefreshToken: req.RefreshToken}
+	if err := tc.tu.RevokeToken(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
+
+// GetSessions lists the devices of the login user that are logged in with refresh tokens.
+func (tc *TokenController) GetSessions(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// get sessions usecase
+	input := usecase.GetSessionsUseCaseInput{UserID: loginUser.ID}
+	output, err := tc.tu.GetSessions(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+
+	// send response
+	res := GetSessionsResponse{Sessions: make([]SessionResponse, 0, len(output.Sessions))}
+	for _, s := range output.Sessions {
+		res.Sessions = append(res.Sessions, SessionResponse{
+			ID:         s.ID,
+			Device:     s.Device,
+			AuthTime:   s.AuthTime,
+			LastUsedAt: s.LastUsedAt,
+			ExpiresAt:  s.ExpiresAt,
+		})
+	}
+	return c.JSONPretty(http.StatusOK, res, "  ")
+}
+
+// RevokeSession logs a device of the login user out.
+func (tc *TokenController) RevokeSession(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// revoke session usecase
+	input := usecase.RevokeSessionUseCaseInput{UserID: loginUser.ID, SessionID: c.Param("id")}
+	if err := tc.tu.RevokeSession(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
@@ -0,0 +1,263 @@
+package controller_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+// TestStubTokenUseCase issues "access-token-of-1" for the user test01 with password test01
+// or for the refresh token "refresh-token", and verifies "access-token-of-<user id>".
+type TestStubTokenUseCase struct {
+	revoked  []string
+	sessions map[int][]usecase.Session
+}
+
+func (s *TestStubTokenUseCase) IssueToken(_ context.Context, input usecase.IssueTokenUseCaseInput) (*usecase.IssueTokenUseCaseOutput, error) {
+	switch input.GrantType {
+	case usecase.GrantTypePassword:
+		if input.Name != "test01" || input.Password != "test01" {
+			return nil, usecase.ErrLoginFailed
+		}
+	case usecase.GrantTypeRefreshToken:
+		if input.RefreshToken != "refresh-token" {
+			return nil, usecase.ErrInvalidToken
+		}
+	default:
+		return nil, usecase.ErrUnsupportedGrantType
+	}
+	return &usecase.IssueTokenUseCaseOutput{
+		AccessToken:  "access-token-of-1",
+		ExpiresIn:    15 * time.Minute,
+		RefreshToken: "new-refresh-token",
+	}, nil
+}
+
+func (s *TestStubTokenUseCase) RevokeToken(_ context.Context, input usecase.RevokeTokenUseCaseInput) error {
+	s.revoked = append(s.revoked, input.RefreshToken)
+	return nil
+}
+
+func (s *TestStubTokenUseCase) GetSessions(_ context.Context, input usecase.GetSessionsUseCaseInput) (*usecase.GetSessionsUseCaseOutput, error) {
+	return &usecase.GetSessionsUseCaseOutput{Sessions: s.sessions[input.UserID]}, nil
+}
+
+func (s *TestStubTokenUseCase) RevokeSession(_ context.Context, input usecase.RevokeSessionUseCaseInput) error {
+	for i, session := range s.sessions[input.UserID] {
+		if session.ID == input.SessionID {
+			s.sessions[input.UserID] = append(s.sessions[input.UserID][:i], s.sessions[input.UserID][i+1:]...)
+			return nil
+		}
+	}
+	return usecase.ErrSessionNotFound
+}
+
+func (s *TestStubTokenUseCase) VerifyAccessToken(_ context.Context, input usecase.VerifyAccessTokenUseCaseInput) (*usecase.VerifyAccessTokenUseCaseOutput, error) {
+	switch input.AccessToken {
+	case "access-token-of-1":
+		return &usecase.VerifyAccessTokenUseCaseOutput{UserID: 1}, nil
+	case "access-token-of-2":
+		return &usecase.VerifyAccessTokenUseCaseOutput{UserID: 2}, nil
+	}
+	return nil, usecase.ErrInvalidAccessToken
+}
+
+func TestIssueToken(t *testing.T) {
+	cases := []struct {
+		name     string
+		reqBody  string
+		wantCode int
+		wantErr  controller.ErrorCode
+	}{
+		{
+			name:     "password grant",
+			reqBody:  `{"grant_type": "password", "name": "test01", "password": "test01"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "refresh token grant",
+			reqBody:  `{"grant_type": "refresh_token", "refresh_token": "refresh-token"}`,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "wrong password",
+			reqBody:  `{"grant_type": "password", "name": "test01", "password": "wrong"}`,
+			wantCode: http.StatusUnauthorized,
+			wantErr:  controller.CodeLoginFailed,
+		},
+		{
+			name:     "used refresh token",
+			reqBody:  `{"grant_type": "refresh_token", "refresh_token": "used"}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  controller.CodeInvalidToken,
+		},
+		{
+			name:     "unknown grant type",
+			reqBody:  `{"grant_type": "client_credentials"}`,
+			wantCode: http.StatusBadRequest,
+			wantErr:  controller.CodeValidationFailed,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			e := echo.New()
+			e.Validator = validator.NewCustomValidator()
+			req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(tt.reqBody))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			tc := controller.NewTokenController(&TestStubTokenUseCase{})
+
+			// Assertions
+			err := tc.IssueToken(c)
+			if tt.wantCode != http.StatusOK {
+				problem := assertProblem(t, c, err, tt.wantCode)
+				assert.Equal(t, tt.wantErr, problem.Code)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, "no-store", rec.Header().Get(echo.HeaderCacheControl))
+
+				var res controller.IssueTokenResponse
+				if assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res)) {
+					assert.Equal(t, controller.IssueTokenResponse{
+						AccessToken:  "access-token-of-1",
+						TokenType:    "Bearer",
+						ExpiresIn:    900,
+						RefreshToken: "new-refresh-token",
+					}, res)
+				}
+			}
+		})
+	}
+}
+
+func TestRevokeToken(t *testing.T) {
+	// Setup
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+	req := httptest.NewRequest(http.MethodPost, "/token/revoke", strings.NewReader(`{"refresh_token": "refresh-token"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	tu := &TestStubTokenUseCase{}
+	tc := controller.NewTokenController(tu)
+
+	// Assertions
+	if assert.NoError(t, tc.RevokeToken(c)) {
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Equal(t, []string{"refresh-token"}, tu.revoked)
+	}
+}
+
+func TestGetSessions(t *testing.T) {
+	authTime := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	tu := &TestStubTokenUseCase{sessions: map[int][]usecase.Session{
+		1: {{ID: "family-1", Device: "app/1.0", AuthTime: authTime, LastUsedAt: authTime.Add(time.Hour), ExpiresAt: authTime.Add(720 * time.Hour)}},
+	}}
+	tc := controller.NewTokenController(tu)
+
+	cases := []struct {
+		name      string
+		loginUser controller.LoginUser
+		want      string
+	}{
+		{
+			name:      "sessions of the login user",
+			loginUser: controller.LoginUser{ID: 1, Name: "test01"},
+			want: `{
+  "sessions": [
+    {
+      "id": "family-1",
+      "device": "app/1.0",
+      "auth_time": "2026-10-01T00:00:00Z",
+      "last_used_at": "2026-10-01T01:00:00Z",
+      "expires_at": "2026-10-31T00:00:00Z"
+    }
+  ]
+}
+`,
+		},
+		{
+			name:      "no sessions",
+			loginUser: controller.LoginUser{ID: 2, Name: "test02"},
+			want: `{
+  "sessions": []
+}
+`,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/me/sessions", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			controller.SetLoginUser(c, tt.loginUser)
+
+			// Assertions
+			if assert.NoError(t, tc.GetSessions(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+				assert.Equal(t, tt.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestRevokeSession(t *testing.T) {
+	cases := []struct {
+		name      string
+		sessionID string
+		wantCode  int
+	}{
+		{name: "own session", sessionID: "family-1", wantCode: http.StatusNoContent},
+		{name: "session of another user", sessionID: "family-2", wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			tu := &TestStubTokenUseCase{sessions: map[int][]usecase.Session{
+				1: {{ID: "family-1"}},
+				2: {{ID: "family-2"}},
+			}}
+			tc := controller.NewTokenController(tu)
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodDelete, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/me/sessions/:id")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+			// Assertions
+			err := tc.RevokeSession(c)
+			if tt.wantCode != http.StatusNoContent {
+				problem := assertProblem(t, c, err, tt.wantCode)
+				assert.Equal(t, controller.CodeSessionNotFound, problem.Code)
+				assert.Len(t, tu.sessions[2], 1)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusNoContent, rec.Code)
+				assert.Empty(t, tu.sessions[1])
+			}
+		})
+	}
+}
@@ -1,20 +1,24 @@
 package controller
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/gorilla/sessions"
+	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
 	"github.com/ricky2122/go-echo-example/domain"
 	"github.com/ricky2122/go-echo-example/usecase"
 )
 
 type SignUpRequest struct {
-	Name     string `json:"name" validate:"required"`
-	Password string `json:"password" validate:"required"`
-	Email    string `json:"email" validate:"required,email"`
-	BirthDay string `json:"birth_day" validate:"required"`
+	Name     string `json:"name" validate:"required,username"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+	Email    string `json:"email" validate:"required,email,max=64"`
+	BirthDay string `json:"birth_day" validate:"required,birthday"`
 }
 
 type SignUpResponse struct {
@@ -33,14 +37,47 @@ type GetUserResponse struct {
 	BirthDay string `json:"birth_day"`
 }
 
+// UpdateUserRequest holds the fields to change; omitted fields are left as they are.
+type UpdateUserRequest struct {
+	ID       int     `param:"id" validate:"gte=1"`
+	Name     *string `json:"name" validate:"omitempty,username"`
+	Email    *string `json:"email" validate:"omitempty,email,max=64"`
+	BirthDay *string `json:"birth_day" validate:"omitempty,birthday"`
+}
+
+type DeleteUserRequest struct {
+	ID int `param:"id" validate:"gte=1"`
+}
+
+type RestoreUserRequest struct {
+	ID int `param:"id" validate:"gte=1"`
+}
+
+type GetUsersRequest struct {
+	Limit        int    `query:"limit" validate:"omitempty,gte=1,lte=100"`
+	Cursor       string `query:"cursor"`
+	Sort         string `query:"sort" validate:"omitempty,oneof=id -id name -name birth_day -birth_day"`
+	NamePrefix   string `query:"name_prefix"`
+	EmailDomain  string `query:"email_domain"`
+	BirthDayFrom string `query:"birth_day_from" validate:"omitempty,datetime=2006-01-02"`
+	BirthDayTo   string `query:"birth_day_to" validate:"omitempty,datetime=2006-01-02"`
+}
+
 type GetUsersResponse struct {
-	Users []GetUserResponse `json:"users"`
+	Users      []GetUserResponse `json:"users"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+	TotalCount int               `json:"total_count"`
 }
 
 type IUserUseCase interface {
-	SignUp(usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error)
-	GetUser(usecase.GetUserUseCaseInput) (*usecase.GetUserUseCaseOutput, error)
-	GetUsers() (*usecase.GetUsersUseCaseOutput, error)
+	SignUp(context.Context, usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error)
+	GetUser(context.Context, usecase.GetUserUseCaseInput) (*usecase.GetUserUseCaseOutput, error)
+	GetUsers(context.Context, usecase.GetUsersUseCaseInput) (*usecase.GetUsersUseCaseOutput, error)
+	UpdateUser(context.Context, usecase.UpdateUserUseCaseInput) (*usecase.UpdateUserUseCaseOutput, error)
+	DeleteUser(context.Context, usecase.DeleteUserUseCaseInput) error
+	RestoreUser(context.Context, usecase.RestoreUserUseCaseInput) error
+	VerifyEmail(context.Context, usecase.VerifyEmailUseCaseInput) error
+	ResendVerificationEmail(context.Context, usecase.ResendVerificationEmailUseCaseInput) error
 }
 
 type UserController struct {
@@ -55,7 +92,7 @@ func (uc *UserController) SignUp(c echo.Context) error {
 	// parse request
 	req := new(SignUpRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+		return invalidRequest(err)
 	}
 
 	// validate
@@ -66,7 +103,7 @@ func (uc *UserController) SignUp(c echo.Context) error {
 	// parse birthday to time.Time from string(YYYY-mm-dd)
 	parseBirthDay, err := time.Parse(domain.BirthDayLayout, req.BirthDay)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid date format")
+		return NewHTTPError(http.StatusBadRequest, CodeInvalidBirthDay, "invalid date format")
 	}
 
 	// sign up usecase
@@ -76,12 +113,9 @@ func (uc *UserController) SignUp(c echo.Context) error {
 		Email:    req.Email,
 		BirthDay: parseBirthDay,
 	}
-	output, err := uc.uuc.SignUp(input)
+	output, err := uc.uuc.SignUp(c.Request().Context(), input)
 	if err != nil {
-		if errors.Is(err, usecase.ErrUserAlreadyExists) {
-			return echo.NewHTTPError(http.StatusBadRequest, "user already exists")
-		}
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
 	}
 
 	// send response
@@ -97,7 +131,7 @@ func (uc *UserController) GetUser(c echo.Context) error {
 	// parse request
 	req := new(GetUserRequest)
 	if err := c.Bind(req); err != nil {
-		return echo.NewHTTPError(http.StatusBadRequest, "invalid request")
+		return invalidRequest(err)
 	}
 
 	// validate
@@ -107,12 +141,83 @@ func (uc *UserController) GetUser(c echo.Context) error {
 
 	// get user usecase
 	input := usecase.GetUserUseCaseInput{ID: req.ID}
-	output, err := uc.uuc.GetUser(input)
+	output, err := uc.uuc.GetUser(c.Request().Context(), input)
+	if err != nil {
+		return err
+	}
+
+	// send response
+	res := GetUserResponse{
+		ID:       output.ID,
+		Name:     output.Name,
+		Email:    output.Email,
+		BirthDay: output.BirthDay,
+	}
+	return c.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func (uc *UserController) GetMe(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// get user usecase
+	input := usecase.GetUserUseCaseInput{ID: loginUser.ID}
+	output, err := uc.uuc.GetUser(c.Request().Context(), input)
 	if err != nil {
 		if errors.Is(err, usecase.ErrUserNotFound) {
-			return echo.NewHTTPError(http.StatusNotFound, "user not found")
+			return errUnauthorized
+		}
+		return err
+	}
+
+	// send response
+	res := GetUserResponse{
+		ID:       output.ID,
+		Name:     output.Name,
+		Email:    output.Email,
+		BirthDay: output.BirthDay,
+	}
+	return c.JSONPretty(http.StatusOK, res, "  ")
+}
+
+func (uc *UserController) UpdateUser(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// parse request
+	req := new(UpdateUserRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// update user usecase
+	input := usecase.UpdateUserUseCaseInput{
+		LoginUserID: loginUser.ID,
+		ID:          req.ID,
+		Name:        req.Name,
+		Email:       req.Email,
+	}
+	if req.BirthDay != nil {
+		parseBirthDay, err := time.Parse(domain.BirthDayLayout, *req.BirthDay)
+		if err != nil {
+			return NewHTTPError(http.StatusBadRequest, CodeInvalidBirthDay, "invalid date format")
 		}
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		input.BirthDay = &parseBirthDay
+	}
+	output, err := uc.uuc.UpdateUser(c.Request().Context(), input)
+	if err != nil {
+		return err
 	}
 
 	// send response
@@ -125,11 +230,107 @@ func (uc *UserController) GetUser(c echo.Context) error {
 	return c.JSONPretty(http.StatusOK, res, "  ")
 }
 
+func (uc *UserController) DeleteUser(c echo.Context) error {
+	// get login user
+	loginUser, ok := GetLoginUser(c)
+	if !ok {
+		return errUnauthorized
+	}
+
+	// parse request
+	req := new(DeleteUserRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// delete user usecase
+	input := usecase.DeleteUserUseCaseInput{LoginUserID: loginUser.ID, ID: req.ID}
+	if err := uc.uuc.DeleteUser(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// the account is closed, so log out
+	sess, err := session.Get(SessionKey, c)
+	if err != nil {
+		return err
+	}
+	sess.Options = &sessions.Options{
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+	}
+	if err := sess.Save(c.Request(), c.Response()); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (uc *UserController) RestoreUser(c echo.Context) error {
+	// parse request
+	req := new(RestoreUserRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// restore user usecase
+	input := usecase.RestoreUserUseCaseInput{ID: req.ID}
+	if err := uc.uuc.RestoreUser(c.Request().Context(), input); err != nil {
+		return err
+	}
+
+	// send response
+	return c.NoContent(http.StatusNoContent)
+}
+
 func (ur *UserController) GetUsers(c echo.Context) error {
+	// parse request
+	req := new(GetUsersRequest)
+	if err := c.Bind(req); err != nil {
+		return invalidRequest(err)
+	}
+
+	// validate
+	if err := c.Validate(req); err != nil {
+		return err
+	}
+
+	// build usecase input from query parameters ("-" prefix means descending order)
+	sortKey, desc := strings.CutPrefix(req.Sort, "-")
+	input := usecase.GetUsersUseCaseInput{
+		Limit:   req.Limit,
+		Cursor:  req.Cursor,
+		SortKey: usecase.UserSortKey(sortKey),
+		Desc:    desc,
+		Filter: usecase.UserFilter{
+			NamePrefix:  req.NamePrefix,
+			EmailDomain: req.EmailDomain,
+		},
+	}
+	if req.BirthDayFrom != "" {
+		birthDayFrom, _ := time.Parse(domain.BirthDayLayout, req.BirthDayFrom)
+		input.Filter.BirthDayFrom = &birthDayFrom
+	}
+	if req.BirthDayTo != "" {
+		birthDayTo, _ := time.Parse(domain.BirthDayLayout, req.BirthDayTo)
+		input.Filter.BirthDayTo = &birthDayTo
+	}
+
 	// get users usecase
-	output, err := ur.uuc.GetUsers()
+	output, err := ur.uuc.GetUsers(c.Request().Context(), input)
 	if err != nil {
-		return echo.NewHTTPError(http.StatusInternalServerError, "internal server error")
+		return err
 	}
 
 	// user is empty
@@ -148,7 +349,11 @@ func (ur *UserController) GetUsers(c echo.Context) error {
 		}
 		users = append(users, userResp)
 	}
-	res := GetUsersResponse{Users: users}
+	res := GetUsersResponse{
+		Users:      users,
+		NextCursor: output.NextCursor,
+		TotalCount: output.TotalCount,
+	}
 
 	return c.JSONPretty(http.StatusOK, res, "  ")
 }
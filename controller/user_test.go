@@ -1,15 +1,17 @@
 package controller_test
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/ricky2122/go-echo-example/controller"
-	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
 	"github.com/ricky2122/go-echo-example/usecase"
 	"github.com/stretchr/testify/assert"
 )
@@ -18,9 +20,12 @@ type TestStubUserUseCase struct {
 	signUpOutputStore   map[string]*usecase.SignUpUseCaseOutput
 	getUserOutputStore  map[int]*usecase.GetUserUseCaseOutput
 	getUsersOutputStore usecase.GetUsersUseCaseOutput
+	getUsersInput       usecase.GetUsersUseCaseInput
+	updateUserInput     usecase.UpdateUserUseCaseInput
+	err                 error
 }
 
-func (s *TestStubUserUseCase) SignUp(input usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error) {
+func (s *TestStubUserUseCase) SignUp(_ context.Context, input usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error) {
 	_, ok := s.signUpOutputStore[input.Name]
 	if ok {
 		return nil, usecase.ErrUserAlreadyExists
@@ -31,7 +36,7 @@ func (s *TestStubUserUseCase) SignUp(input usecase.SignUpUseCaseInput) (*usecase
 	return output, nil
 }
 
-func (s *TestStubUserUseCase) GetUser(input usecase.GetUserUseCaseInput) (*usecase.GetUserUseCaseOutput, error) {
+func (s *TestStubUserUseCase) GetUser(_ context.Context, input usecase.GetUserUseCaseInput) (*usecase.GetUserUseCaseOutput, error) {
 	output, ok := s.getUserOutputStore[input.ID]
 	if !ok {
 		return nil, usecase.ErrUserNotFound
@@ -39,14 +44,51 @@ func (s *TestStubUserUseCase) GetUser(input usecase.GetUserUseCaseInput) (*useca
 	return output, nil
 }
 
-func (s *TestStubUserUseCase) GetUsers() (*usecase.GetUsersUseCaseOutput, error) {
+func (s *TestStubUserUseCase) GetUsers(_ context.Context, input usecase.GetUsersUseCaseInput) (*usecase.GetUsersUseCaseOutput, error) {
+	s.getUsersInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
 	return &s.getUsersOutputStore, nil
 }
 
+func (s *TestStubUserUseCase) UpdateUser(_ context.Context, input usecase.UpdateUserUseCaseInput) (*usecase.UpdateUserUseCaseOutput, error) {
+	s.updateUserInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	output := &usecase.UpdateUserUseCaseOutput{
+		ID:       input.ID,
+		Name:     "test01",
+		Email:    "test01@test.com",
+		BirthDay: "2001-01-01",
+	}
+	if input.Name != nil {
+		output.Name = *input.Name
+	}
+	return output, nil
+}
+
+func (s *TestStubUserUseCase) DeleteUser(_ context.Context, _ usecase.DeleteUserUseCaseInput) error {
+	return s.err
+}
+
+func (s *TestStubUserUseCase) RestoreUser(_ context.Context, _ usecase.RestoreUserUseCaseInput) error {
+	return s.err
+}
+
+func (s *TestStubUserUseCase) VerifyEmail(_ context.Context, _ usecase.VerifyEmailUseCaseInput) error {
+	return s.err
+}
+
+func (s *TestStubUserUseCase) ResendVerificationEmail(_ context.Context, _ usecase.ResendVerificationEmailUseCaseInput) error {
+	return s.err
+}
+
 func TestSignUp(t *testing.T) {
 	signUpReq01 := `{
 		"name":"test01",
-		"password":"test01",
+		"password":"password01",
 		"email":"test01@test.com",
 		"birth_day":"2001-01-01"
 	  }
@@ -60,7 +102,7 @@ func TestSignUp(t *testing.T) {
 
 	signUpReq02 := `{
 		"name":"test02",
-		"password":"test02",
+		"password":"password02",
 		"email":"test02@test.com",
 		"birth_day":"2002-01-01"
 	  }
@@ -73,7 +115,7 @@ func TestSignUp(t *testing.T) {
 	  `
 	// Setup
 	e := echo.New()
-	e.Validator = api.NewCustomValidator()
+	e.Validator = validator.NewCustomValidator()
 
 	t.Run("StatusCreated", func(t *testing.T) {
 		store := map[string]*usecase.SignUpUseCaseOutput{}
@@ -113,7 +155,7 @@ func TestSignUp(t *testing.T) {
 		}
 	})
 
-	t.Run("StatusBadRequest", func(t *testing.T) {
+	t.Run("StatusConflict", func(t *testing.T) {
 		userAlreadyExistsMsg := "user already exists"
 
 		store := map[string]*usecase.SignUpUseCaseOutput{}
@@ -129,13 +171,27 @@ func TestSignUp(t *testing.T) {
 
 			// Assertions
 			err := uc.SignUp(c)
-			if assert.NotNil(t, err) {
-				err, res := err.(*echo.HTTPError)
-				if res {
-					assert.Equal(t, http.StatusBadRequest, err.Code)
-					assert.Equal(t, userAlreadyExistsMsg, err.Message)
-				}
-			}
+			problem := assertProblem(t, c, err, http.StatusConflict)
+			assert.Equal(t, controller.CodeUserAlreadyExists, problem.Code)
+			assert.Equal(t, userAlreadyExistsMsg, problem.Detail)
+		})
+	})
+
+	t.Run("StatusBadRequest", func(t *testing.T) {
+		uc := controller.NewUserController(&TestStubUserUseCase{})
+
+		t.Run("short password", func(t *testing.T) {
+			reqJSON := strings.Replace(signUpReq01, `"password":"password01"`, `"password":"short"`, 1)
+			req := httptest.NewRequest(http.MethodPost, "/signup", strings.NewReader(reqJSON))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			c := e.NewContext(req, rec)
+
+			// Assertions
+			err := uc.SignUp(c)
+			problem := assertProblem(t, c, err, http.StatusBadRequest)
+			assert.Equal(t, controller.CodeValidationFailed, problem.Code)
 		})
 	})
 }
@@ -161,7 +217,7 @@ func TestGetUser(t *testing.T) {
 	t.Run("StatusOK", func(t *testing.T) {
 		// Setup
 		e := echo.New()
-		e.Validator = api.NewCustomValidator()
+		e.Validator = validator.NewCustomValidator()
 
 		store := map[int]*usecase.GetUserUseCaseOutput{}
 		store[1] = &usecase.GetUserUseCaseOutput{
@@ -220,7 +276,7 @@ func TestGetUser(t *testing.T) {
 	t.Run("StatusNotFound", func(t *testing.T) {
 		// Set up
 		e := echo.New()
-		e.Validator = api.NewCustomValidator()
+		e.Validator = validator.NewCustomValidator()
 
 		// Create a test user controller with a stub user use case
 		store := map[int]*usecase.GetUserUseCaseOutput{}
@@ -241,24 +297,71 @@ func TestGetUser(t *testing.T) {
 
 		// Assertions
 		err := uc.GetUser(c)
-		if assert.NotNil(t, err) {
-			err, res := err.(*echo.HTTPError)
-			if res {
-				assert.Equal(t, http.StatusNotFound, err.Code)
-				assert.Equal(t, userNotFoundMsg, err.Message)
-			}
+		problem := assertProblem(t, c, err, http.StatusNotFound)
+		assert.Equal(t, controller.CodeUserNotFound, problem.Code)
+		assert.Equal(t, userNotFoundMsg, problem.Detail)
+	})
+}
+
+func TestGetMe(t *testing.T) {
+	getMeRes := `{
+		"id": 1,
+		"name": "test01",
+		"email": "test01@test.com",
+		"birth_day": "2001-01-01"
+	  }
+	  `
+
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	store := map[int]*usecase.GetUserUseCaseOutput{}
+	store[1] = &usecase.GetUserUseCaseOutput{
+		ID:       1,
+		Name:     "test01",
+		Email:    "test01@test.com",
+		BirthDay: "2001-01-01",
+	}
+	uc := controller.NewUserController(&TestStubUserUseCase{
+		getUserOutputStore: store,
+	})
+
+	t.Run("StatusOK", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+		// Assertions
+		if assert.NoError(t, uc.GetMe(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, getMeRes, rec.Body.String())
 		}
 	})
+
+	t.Run("StatusUnauthorized", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+
+		// Assertions
+		err := uc.GetMe(c)
+		assertProblem(t, c, err, http.StatusUnauthorized)
+	})
 }
 
 func TestGetUsers(t *testing.T) {
 	// Set up
 	e := echo.New()
-	e.Validator = api.NewCustomValidator()
+	e.Validator = validator.NewCustomValidator()
 
 	getUsersEmptyRes := `
 	{
-  	  "users": []
+  	  "users": [],
+  	  "total_count": 0
     }`
 	getUsersTwoUsersRes := `
 	{
@@ -275,7 +378,9 @@ func TestGetUsers(t *testing.T) {
 			"email": "test02@test.com",
 			"birth_day": "2002-01-01"
 		}
-	  ]
+	  ],
+	  "next_cursor": "next",
+	  "total_count": 3
 	}
 	`
 	t.Run("StatusOK", func(t *testing.T) {
@@ -317,6 +422,8 @@ func TestGetUsers(t *testing.T) {
 							BirthDay: "2002-01-01",
 						},
 					},
+					NextCursor: "next",
+					TotalCount: 3,
 				}
 			}
 			uc := controller.NewUserController(&TestStubUserUseCase{
@@ -338,3 +445,233 @@ func TestGetUsers(t *testing.T) {
 		}
 	})
 }
+
+func TestGetUsersQuery(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	t.Run("query parameters", func(t *testing.T) {
+		stub := &TestStubUserUseCase{}
+		uc := controller.NewUserController(stub)
+
+		target := "/users?limit=10&cursor=abc&sort=-birth_day&name_prefix=test&email_domain=test.com&birth_day_from=2001-01-01&birth_day_to=2002-12-31"
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+
+		// Assertions
+		if assert.NoError(t, uc.GetUsers(c)) {
+			birthDayFrom := time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+			birthDayTo := time.Date(2002, 12, 31, 0, 0, 0, 0, time.UTC)
+			want := usecase.GetUsersUseCaseInput{
+				Limit:   10,
+				Cursor:  "abc",
+				SortKey: usecase.UserSortByBirthDay,
+				Desc:    true,
+				Filter: usecase.UserFilter{
+					NamePrefix:   "test",
+					EmailDomain:  "test.com",
+					BirthDayFrom: &birthDayFrom,
+					BirthDayTo:   &birthDayTo,
+				},
+			}
+			assert.Equal(t, want, stub.getUsersInput)
+		}
+	})
+
+	t.Run("StatusBadRequest", func(t *testing.T) {
+		cases := []struct {
+			name   string
+			target string
+			err    error
+		}{
+			{name: "limit too large", target: "/users?limit=101"},
+			{name: "unknown sort key", target: "/users?sort=email"},
+			{name: "invalid birth day", target: "/users?birth_day_from=2001-13-01"},
+			{name: "invalid cursor", target: "/users?cursor=abc", err: usecase.ErrInvalidCursor},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+
+				req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+				rec := httptest.NewRecorder()
+				c := e.NewContext(req, rec)
+
+				// Assertions
+				err := uc.GetUsers(c)
+				assertProblem(t, c, err, http.StatusBadRequest)
+			})
+		}
+	})
+}
+
+func TestUpdateUser(t *testing.T) {
+	updateUserReq := `{
+		"name": "updated01"
+	  }
+	  `
+
+	updateUserRes := `{
+		"id": 1,
+		"name": "updated01",
+		"email": "test01@test.com",
+		"birth_day": "2001-01-01"
+	  }
+	  `
+
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	newContext := func(reqJSON string) (echo.Context, *httptest.ResponseRecorder) {
+		req := httptest.NewRequest(http.MethodPatch, "/users/1", strings.NewReader(reqJSON))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+
+		c := e.NewContext(req, rec)
+		c.SetPath("/users/:id")
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+		controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+		return c, rec
+	}
+
+	t.Run("StatusOK", func(t *testing.T) {
+		stub := &TestStubUserUseCase{}
+		uc := controller.NewUserController(stub)
+		c, rec := newContext(updateUserReq)
+
+		// Assertions
+		if assert.NoError(t, uc.UpdateUser(c)) {
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.JSONEq(t, updateUserRes, rec.Body.String())
+			assert.Equal(t, 1, stub.updateUserInput.LoginUserID)
+			assert.Nil(t, stub.updateUserInput.Email)
+			assert.Nil(t, stub.updateUserInput.BirthDay)
+		}
+	})
+
+	t.Run("error status", func(t *testing.T) {
+		cases := []struct {
+			name     string
+			reqJSON  string
+			err      error
+			wantCode int
+		}{
+			{name: "invalid email", reqJSON: `{"email": "test01"}`, wantCode: http.StatusBadRequest},
+			{name: "invalid birth day", reqJSON: `{"birth_day": "2001-13-01"}`, wantCode: http.StatusBadRequest},
+			{name: "forbidden", reqJSON: updateUserReq, err: usecase.ErrForbidden, wantCode: http.StatusForbidden},
+			{name: "not found", reqJSON: updateUserReq, err: usecase.ErrUserNotFound, wantCode: http.StatusNotFound},
+			{name: "conflict", reqJSON: updateUserReq, err: usecase.ErrUserAlreadyExists, wantCode: http.StatusConflict},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+				c, _ := newContext(tt.reqJSON)
+
+				// Assertions
+				err := uc.UpdateUser(c)
+				assertProblem(t, c, err, tt.wantCode)
+			})
+		}
+	})
+}
+
+func TestDeleteUser(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "no content", wantCode: http.StatusNoContent},
+		{name: "forbidden", err: usecase.ErrForbidden, wantCode: http.StatusForbidden},
+		{name: "not found", err: usecase.ErrUserNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/users/:id")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+			c.Set("_session_store", &TestStubFixedSessionStore{})
+			controller.SetLoginUser(c, controller.LoginUser{ID: 1, Name: "test01"})
+
+			// Assertions
+			err := uc.DeleteUser(c)
+			if tt.err == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantCode, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestRestoreUser(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	cases := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "no content", wantCode: http.StatusNoContent},
+		{name: "not found", err: usecase.ErrUserNotFound, wantCode: http.StatusNotFound},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			uc := controller.NewUserController(&TestStubUserUseCase{err: tt.err})
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/users/1/restore", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/admin/users/:id/restore")
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			// Assertions
+			err := uc.RestoreUser(c)
+			if tt.err == nil {
+				if assert.NoError(t, err) {
+					assert.Equal(t, tt.wantCode, rec.Code)
+				}
+				return
+			}
+			assertProblem(t, c, err, tt.wantCode)
+		})
+	}
+}
+
+func TestGetUsersTimeout(t *testing.T) {
+	// Set up
+	e := echo.New()
+	e.Validator = validator.NewCustomValidator()
+
+	uc := controller.NewUserController(&TestStubUserUseCase{err: context.DeadlineExceeded})
+
+	req := httptest.NewRequest(http.MethodGet, "/users", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+
+	// Assertions
+	err := uc.GetUsers(c)
+	assertProblem(t, c, err, http.StatusServiceUnavailable)
+}
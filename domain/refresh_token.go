@@ -0,0 +1,97 @@
+package domain
+
+import "time"
+
+// RefreshToken lets a token client get new access tokens without the password.
+// Only the hash of the token is kept, and the token is replaced by a new one on every use.
+// The tokens replacing each other form a family, which is the login of one device.
+type RefreshToken struct {
+	userID    UserID
+	familyID  string
+	tokenHash string
+	device    string
+	authTime  time.Time
+	createdAt time.Time
+	expiresAt time.Time
+	usedAt    time.Time
+	revokedAt time.Time
+}
+
+// NewRefreshToken returns a token of the family, which was logged in with the password at authTime.
+func NewRefreshToken(userID UserID, familyID, tokenHash, device string, authTime, expiresAt time.Time) RefreshToken {
+	return RefreshToken{
+		userID:    userID,
+		familyID:  familyID,
+		tokenHash: tokenHash,
+		device:    device,
+		authTime:  authTime,
+		createdAt: time.Now(),
+		expiresAt: expiresAt,
+	}
+}
+
+// ReconstructRefreshToken restores a stored token.
+func ReconstructRefreshToken(
+	userID UserID,
+	familyID, tokenHash, device string,
+	authTime, createdAt, expiresAt, usedAt, revokedAt time.Time,
+) RefreshToken {
+	return RefreshToken{
+		userID:    userID,
+		familyID:  familyID,
+		tokenHash: tokenHash,
+		device:    device,
+		authTime:  authTime,
+		createdAt: createdAt,
+		expiresAt: expiresAt,
+		usedAt:    usedAt,
+		revokedAt: revokedAt,
+	}
+}
+
+// Rotate returns the token replacing this one in the family.
+func (t *RefreshToken) Rotate(tokenHash string, expiresAt time.Time) RefreshToken {
+	return NewRefreshToken(t.userID, t.familyID, tokenHash, t.device, t.authTime, expiresAt)
+}
+
+func (t *RefreshToken) GetUserID() UserID {
+	return t.userID
+}
+
+func (t *RefreshToken) GetFamilyID() string {
+	return t.familyID
+}
+
+func (t *RefreshToken) GetTokenHash() string {
+	return t.tokenHash
+}
+
+// GetDevice returns the User-Agent of the login.
+func (t *RefreshToken) GetDevice() string {
+	return t.device
+}
+
+func (t *RefreshToken) GetAuthTime() time.Time {
+	return t.authTime
+}
+
+func (t *RefreshToken) GetCreatedAt() time.Time {
+	return t.createdAt
+}
+
+func (t *RefreshToken) GetExpiresAt() time.Time {
+	return t.expiresAt
+}
+
+// IsUsed reports whether the token has been replaced. Presenting a used token again means that it has leaked.
+func (t *RefreshToken) IsUsed() bool {
+	return !t.usedAt.IsZero()
+}
+
+func (t *RefreshToken) IsRevoked() bool {
+	return !t.revokedAt.IsZero()
+}
+
+func (t *RefreshToken) IsExpired(now time.Time) bool {
+	return !t.expiresAt.After(now)
+}
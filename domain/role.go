@@ -0,0 +1,75 @@
+package domain
+
+import (
+	"errors"
+	"slices"
+)
+
+var ErrUnknownRole = errors.New("unknown role")
+
+// Permission is an action on a resource, written as "<resource>:<action>".
+type Permission string
+
+const (
+	PermissionUsersRead    Permission = "users:read"
+	PermissionUsersList    Permission = "users:list"
+	PermissionUsersRestore Permission = "users:restore"
+	PermissionRolesRead    Permission = "roles:read"
+	PermissionRolesAssign  Permission = "roles:assign"
+)
+
+type RoleName string
+
+const (
+	// RoleMember is given to every user at sign-up
+	RoleMember RoleName = "member"
+	RoleAdmin  RoleName = "admin"
+)
+
+// rolePermissions defines the permissions granted by each role.
+// The roles table holds the same names, so that user_roles can only refer to known roles.
+var rolePermissions = map[RoleName][]Permission{
+	RoleMember: {
+		PermissionUsersRead,
+		PermissionUsersList,
+	},
+	RoleAdmin: {
+		PermissionUsersRead,
+		PermissionUsersList,
+		PermissionUsersRestore,
+		PermissionRolesRead,
+		PermissionRolesAssign,
+	},
+}
+
+type Role struct {
+	name        RoleName
+	permissions []Permission
+}
+
+func NewRole(name string) (Role, error) {
+	permissions, ok := rolePermissions[RoleName(name)]
+	if !ok {
+		return Role{}, ErrUnknownRole
+	}
+	return Role{name: RoleName(name), permissions: permissions}, nil
+}
+
+func (r Role) GetName() RoleName {
+	return r.name
+}
+
+func (r Role) GetPermissions() []Permission {
+	return slices.Clone(r.permissions)
+}
+
+func (r Role) HasPermission(permission Permission) bool {
+	return slices.Contains(r.permissions, permission)
+}
+
+// HasPermission reports whether any of the roles grants the permission.
+func HasPermission(roles []Role, permission Permission) bool {
+	return slices.ContainsFunc(roles, func(r Role) bool {
+		return r.HasPermission(permission)
+	})
+}
@@ -0,0 +1,46 @@
+package domain_test
+
+import (
+	"testing"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRole(t *testing.T) {
+	t.Run("known role", func(t *testing.T) {
+		role, err := domain.NewRole("admin")
+		if assert.NoError(t, err) {
+			assert.Equal(t, domain.RoleAdmin, role.GetName())
+			assert.True(t, role.HasPermission(domain.PermissionRolesAssign))
+		}
+	})
+
+	t.Run("unknown role", func(t *testing.T) {
+		_, err := domain.NewRole("owner")
+		assert.ErrorIs(t, err, domain.ErrUnknownRole)
+	})
+}
+
+func TestHasPermission(t *testing.T) {
+	member, _ := domain.NewRole("member")
+	admin, _ := domain.NewRole("admin")
+
+	cases := []struct {
+		name       string
+		roles      []domain.Role
+		permission domain.Permission
+		want       bool
+	}{
+		{name: "member lists users", roles: []domain.Role{member}, permission: domain.PermissionUsersList, want: true},
+		{name: "member cannot assign roles", roles: []domain.Role{member}, permission: domain.PermissionRolesAssign, want: false},
+		{name: "admin assigns roles", roles: []domain.Role{member, admin}, permission: domain.PermissionRolesAssign, want: true},
+		{name: "no roles", roles: nil, permission: domain.PermissionUsersRead, want: false},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, domain.HasPermission(tt.roles, tt.permission))
+		})
+	}
+}
@@ -1,8 +1,38 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
 
-const BirthDayLayout = "2006-01-02"
+const (
+	BirthDayLayout = "2006-01-02"
+
+	// limits of the users table columns
+	MaxUserNameLength = 32
+	MaxEmailLength    = 64
+
+	// limits of passwords in bytes, as bcrypt ignores everything after 72 bytes
+	MinPasswordLength = 8
+	MaxPasswordLength = 72
+
+	// MinUserAge is the age a user must have reached to sign up
+	MinUserAge = 13
+)
+
+var (
+	ErrInvalidUserName = errors.New("invalid user name")
+	ErrInvalidEmail    = errors.New("invalid email")
+	ErrInvalidBirthDay = errors.New("invalid birth day")
+	ErrUserTooYoung    = errors.New("user is too young")
+	ErrInvalidPassword = errors.New("invalid password")
+)
+
+// userNamePattern matches the letters, digits and symbols allowed in user names
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
 
 type UserID int
 
@@ -10,8 +40,61 @@ func (i UserID) Int() int {
 	return int(i)
 }
 
+type UserName string
+
+// NewUserName validates the name: 1 to MaxUserNameLength letters, digits, '_', '-' or '.'.
+func NewUserName(name string) (UserName, error) {
+	if len(name) > MaxUserNameLength || !userNamePattern.MatchString(name) {
+		return "", ErrInvalidUserName
+	}
+	return UserName(name), nil
+}
+
+func (n UserName) String() string {
+	return string(n)
+}
+
+type Email string
+
+// NewEmail validates the address and normalizes it to lowercase, so that it is unique regardless of case.
+func NewEmail(email string) (Email, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if len(email) > MaxEmailLength {
+		return "", ErrInvalidEmail
+	}
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return "", ErrInvalidEmail
+	}
+	return Email(email), nil
+}
+
+func (e Email) String() string {
+	return string(e)
+}
+
+// ValidatePassword checks the plain password of a sign-up or a password change.
+// It is only hashed, so unlike the other fields it has no type of its own.
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength || len(password) > MaxPasswordLength {
+		return ErrInvalidPassword
+	}
+	return nil
+}
+
 type BirthDay time.Time
 
+// NewBirthDay validates that the date is not in the future and the user is at least MinUserAge years old.
+func NewBirthDay(birthDay time.Time) (BirthDay, error) {
+	now := time.Now()
+	if birthDay.After(now) {
+		return BirthDay{}, ErrInvalidBirthDay
+	}
+	if birthDay.AddDate(MinUserAge, 0, 0).After(now) {
+		return BirthDay{}, ErrUserTooYoung
+	}
+	return BirthDay(birthDay), nil
+}
+
 func (b BirthDay) Time() time.Time {
 	return time.Time(b)
 }
@@ -21,19 +104,49 @@ func (b BirthDay) String() string {
 }
 
 type User struct {
-	id       UserID
-	name     string
-	password string
-	email    string
-	birthDay BirthDay
+	id              UserID
+	name            UserName
+	password        string
+	email           Email
+	birthDay        BirthDay
+	emailVerifiedAt time.Time
+	disabledAt      time.Time
 }
 
-func NewUser(name, password, email string, birthDay time.Time) User {
+// NewUser creates a user to sign up, validating every field.
+// The password is set with SetPassword once it has been hashed, which is only worth it for a valid user.
+func NewUser(name, email string, birthDay time.Time) (User, error) {
+	userName, err := NewUserName(name)
+	if err != nil {
+		return User{}, err
+	}
+	userEmail, err := NewEmail(email)
+	if err != nil {
+		return User{}, err
+	}
+	userBirthDay, err := NewBirthDay(birthDay)
+	if err != nil {
+		return User{}, err
+	}
+
+	user := User{
+		name:     userName,
+		email:    userEmail,
+		birthDay: userBirthDay,
+	}
+	return user, nil
+}
+
+// ReconstructUser restores a stored user without validation,
+// so that users stored before a rule was introduced can still be loaded.
+func ReconstructUser(id UserID, name, password, email string, birthDay, emailVerifiedAt time.Time) User {
 	return User{
-		name:     name,
-		password: password,
-		email:    email,
-		birthDay: BirthDay(birthDay),
+		id:              id,
+		name:            UserName(name),
+		password:        password,
+		email:           Email(email),
+		birthDay:        BirthDay(birthDay),
+		emailVerifiedAt: emailVerifiedAt,
 	}
 }
 
@@ -46,17 +159,81 @@ func (u *User) SetID(id int) {
 }
 
 func (u *User) GetName() string {
-	return u.name
+	return u.name.String()
 }
 
 func (u *User) GetPassword() string {
 	return u.password
 }
 
+// SetPassword sets the hashed password.
+func (u *User) SetPassword(hashedPassword string) {
+	u.password = hashedPassword
+}
+
 func (u *User) GetEmail() string {
-	return u.email
+	return u.email.String()
 }
 
 func (u *User) GetBirthDay() BirthDay {
 	return u.birthDay
 }
+
+// GetEmailVerifiedAt returns the zero time if the email has not been verified.
+func (u *User) GetEmailVerifiedAt() time.Time {
+	return u.emailVerifiedAt
+}
+
+func (u *User) SetEmailVerifiedAt(verifiedAt time.Time) {
+	u.emailVerifiedAt = verifiedAt
+}
+
+func (u *User) IsEmailVerified() bool {
+	return !u.emailVerifiedAt.IsZero()
+}
+
+// GetDisabledAt returns the zero time if the user is not disabled.
+func (u *User) GetDisabledAt() time.Time {
+	return u.disabledAt
+}
+
+func (u *User) SetDisabledAt(disabledAt time.Time) {
+	u.disabledAt = disabledAt
+}
+
+// IsDisabled reports whether an administrator has disabled the user, who can then no longer log in.
+func (u *User) IsDisabled() bool {
+	return !u.disabledAt.IsZero()
+}
+
+func (u *User) ChangeName(name string) error {
+	userName, err := NewUserName(name)
+	if err != nil {
+		return err
+	}
+	u.name = userName
+	return nil
+}
+
+func (u *User) ChangeEmail(email string) error {
+	userEmail, err := NewEmail(email)
+	if err != nil {
+		return err
+	}
+
+	// a new address has to be verified again, unlike an address stored in mixed case that is only lowercased
+	if !strings.EqualFold(userEmail.String(), u.email.String()) {
+		u.emailVerifiedAt = time.Time{}
+	}
+	u.email = userEmail
+	return nil
+}
+
+func (u *User) ChangeBirthDay(birthDay time.Time) error {
+	userBirthDay, err := NewBirthDay(birthDay)
+	if err != nil {
+		return err
+	}
+	u.birthDay = userBirthDay
+	return nil
+}
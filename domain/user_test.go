@@ -0,0 +1,127 @@
+package domain_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUserName(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "valid", input: "user_01.test-a"},
+		{name: "max length", input: strings.Repeat("a", domain.MaxUserNameLength)},
+		{name: "empty", input: "", wantErr: domain.ErrInvalidUserName},
+		{name: "too long", input: strings.Repeat("a", domain.MaxUserNameLength+1), wantErr: domain.ErrInvalidUserName},
+		{name: "space", input: "user 01", wantErr: domain.ErrInvalidUserName},
+		{name: "non ascii", input: "ユーザー", wantErr: domain.ErrInvalidUserName},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.NewUserName(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.input, got.String())
+			}
+		})
+	}
+}
+
+func TestNewEmail(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr error
+	}{
+		{name: "valid", input: "test01@test.com", want: "test01@test.com"},
+		{name: "normalized", input: " Test01@Test.COM ", want: "test01@test.com"},
+		{name: "invalid", input: "test01", wantErr: domain.ErrInvalidEmail},
+		{name: "display name", input: "Test <test01@test.com>", wantErr: domain.ErrInvalidEmail},
+		{name: "too long", input: strings.Repeat("a", domain.MaxEmailLength) + "@test.com", wantErr: domain.ErrInvalidEmail},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := domain.NewEmail(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			if assert.NoError(t, err) {
+				assert.Equal(t, tt.want, got.String())
+			}
+		})
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	cases := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "min length", input: strings.Repeat("a", domain.MinPasswordLength)},
+		{name: "max length", input: strings.Repeat("a", domain.MaxPasswordLength)},
+		{name: "too short", input: strings.Repeat("a", domain.MinPasswordLength-1), wantErr: domain.ErrInvalidPassword},
+		{name: "too long", input: strings.Repeat("a", domain.MaxPasswordLength+1), wantErr: domain.ErrInvalidPassword},
+		// the limits are in bytes: 25 three-byte characters are 75 bytes
+		{name: "too long in bytes", input: strings.Repeat("あ", 25), wantErr: domain.ErrInvalidPassword},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, domain.ValidatePassword(tt.input), tt.wantErr)
+		})
+	}
+}
+
+func TestNewBirthDay(t *testing.T) {
+	now := time.Now()
+
+	cases := []struct {
+		name    string
+		input   time.Time
+		wantErr error
+	}{
+		{name: "valid", input: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "just old enough", input: now.AddDate(-domain.MinUserAge, 0, -1)},
+		{name: "too young", input: now.AddDate(-domain.MinUserAge, 0, 1), wantErr: domain.ErrUserTooYoung},
+		{name: "future", input: now.AddDate(0, 0, 1), wantErr: domain.ErrInvalidBirthDay},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := domain.NewBirthDay(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestChangeEmail(t *testing.T) {
+	user := domain.ReconstructUser(1, "test01", "password", "test01@test.com", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Now())
+
+	// the same address in another case stays verified
+	if assert.NoError(t, user.ChangeEmail("TEST01@test.com")) {
+		assert.True(t, user.IsEmailVerified())
+	}
+
+	if assert.NoError(t, user.ChangeEmail("updated01@test.com")) {
+		assert.Equal(t, "updated01@test.com", user.GetEmail())
+		assert.False(t, user.IsEmailVerified())
+	}
+}
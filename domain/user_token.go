@@ -0,0 +1,43 @@
+package domain
+
+import "time"
+
+type UserTokenPurpose string
+
+const (
+	UserTokenPurposePasswordReset     UserTokenPurpose = "password_reset"
+	UserTokenPurposeEmailVerification UserTokenPurpose = "email_verification"
+)
+
+// UserToken is a single-use token sent to the user. Only the hash of the token is kept.
+type UserToken struct {
+	userID    UserID
+	purpose   UserTokenPurpose
+	tokenHash string
+	expiresAt time.Time
+}
+
+func NewUserToken(userID UserID, purpose UserTokenPurpose, tokenHash string, expiresAt time.Time) UserToken {
+	return UserToken{
+		userID:    userID,
+		purpose:   purpose,
+		tokenHash: tokenHash,
+		expiresAt: expiresAt,
+	}
+}
+
+func (t *UserToken) GetUserID() UserID {
+	return t.userID
+}
+
+func (t *UserToken) GetPurpose() UserTokenPurpose {
+	return t.purpose
+}
+
+func (t *UserToken) GetTokenHash() string {
+	return t.tokenHash
+}
+
+func (t *UserToken) GetExpiresAt() time.Time {
+	return t.expiresAt
+}
@@ -0,0 +1,78 @@
+package accesstoken
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// the supported JWT signing algorithms
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// MinHMACSecretLength is the minimum length of an HS256 secret, the size of its hash.
+const MinHMACSecretLength = 32
+
+var ErrVerifyOnlyKey = errors.New("key cannot sign, only verify")
+
+// Key is a signing key identified by the "kid" header of the tokens signed with it.
+type Key struct {
+	ID     string
+	method jwt.SigningMethod
+	// signKey is nil for a public key, which only verifies the tokens signed before it was retired
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// NewHMACKey returns an HS256 key.
+func NewHMACKey(id string, secret []byte) (Key, error) {
+	if len(secret) < MinHMACSecretLength {
+		return Key{}, fmt.Errorf("key %s: HS256 secret must be at least %d bytes", id, MinHMACSecretLength)
+	}
+	return Key{ID: id, method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}, nil
+}
+
+// ParseKey returns the key of the algorithm from its secret (HS256)
+// or from a PEM encoded private or public key (RS256 and EdDSA).
+func ParseKey(id, algorithm string, data []byte) (Key, error) {
+	switch algorithm {
+	case AlgorithmHS256:
+		return NewHMACKey(id, data)
+	case AlgorithmRS256:
+		if privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			return Key{ID: id, method: jwt.SigningMethodRS256, signKey: privateKey, verifyKey: privateKey.Public().(*rsa.PublicKey)}, nil
+		}
+		publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: %w", id, err)
+		}
+		return Key{ID: id, method: jwt.SigningMethodRS256, verifyKey: publicKey}, nil
+	case AlgorithmEdDSA:
+		if privateKey, err := jwt.ParseEdPrivateKeyFromPEM(data); err == nil {
+			return Key{ID: id, method: jwt.SigningMethodEdDSA, signKey: privateKey, verifyKey: privateKey.(ed25519.PrivateKey).Public()}, nil
+		}
+		publicKey, err := jwt.ParseEdPublicKeyFromPEM(data)
+		if err != nil {
+			return Key{}, fmt.Errorf("key %s: %w", id, err)
+		}
+		return Key{ID: id, method: jwt.SigningMethodEdDSA, verifyKey: publicKey}, nil
+	default:
+		return Key{}, fmt.Errorf("key %s: unsupported algorithm %q", id, algorithm)
+	}
+}
+
+// Algorithm returns the JWT "alg" of the key.
+func (k Key) Algorithm() string {
+	return k.method.Alg()
+}
+
+// CanSign reports whether the key holds a secret or private key.
+func (k Key) CanSign() bool {
+	return k.signKey != nil
+}
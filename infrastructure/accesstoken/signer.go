@@ -0,0 +1,89 @@
+// Package accesstoken signs the access tokens as JWTs.
+package accesstoken
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+// Signer signs new tokens with the current key and verifies tokens signed with any of its keys,
+// so that a key can be rotated by adding a new current key and keeping the old one until its tokens have expired.
+type Signer struct {
+	issuer  string
+	current Key
+	keys    map[string]Key
+}
+
+// NewSigner returns a signer signing with the key of currentKeyID.
+func NewSigner(issuer string, currentKeyID string, keys ...Key) (*Signer, error) {
+	s := &Signer{issuer: issuer, keys: make(map[string]Key, len(keys))}
+	for _, key := range keys {
+		if _, ok := s.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicate key %s", key.ID)
+		}
+		s.keys[key.ID] = key
+	}
+
+	current, ok := s.keys[currentKeyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %s", currentKeyID)
+	}
+	if !current.CanSign() {
+		return nil, fmt.Errorf("signing key %s: %w", currentKeyID, ErrVerifyOnlyKey)
+	}
+	s.current = current
+
+	return s, nil
+}
+
+func (s *Signer) Sign(claims usecase.AccessTokenClaims) (string, error) {
+	token := jwt.NewWithClaims(s.current.method, jwt.RegisteredClaims{
+		Issuer:    s.issuer,
+		Subject:   strconv.Itoa(claims.UserID.Int()),
+		IssuedAt:  jwt.NewNumericDate(claims.IssuedAt),
+		ExpiresAt: jwt.NewNumericDate(claims.ExpiresAt),
+	})
+	token.Header["kid"] = s.current.ID
+	return token.SignedString(s.current.signKey)
+}
+
+func (s *Signer) Verify(tokenString string) (*usecase.AccessTokenClaims, error) {
+	claims := &jwt.RegisteredClaims{}
+	// the expiry is optional to the parser unless required
+	_, err := jwt.ParseWithClaims(tokenString, claims, s.keyFunc, jwt.WithExpirationRequired(), jwt.WithIssuer(s.issuer))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", usecase.ErrInvalidAccessToken, err)
+	}
+	userID, err := strconv.Atoi(claims.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("%w: subject %q", usecase.ErrInvalidAccessToken, claims.Subject)
+	}
+
+	output := &usecase.AccessTokenClaims{
+		UserID:    domain.UserID(userID),
+		ExpiresAt: claims.ExpiresAt.Time,
+	}
+	if claims.IssuedAt != nil {
+		output.IssuedAt = claims.IssuedAt.Time
+	}
+	return output, nil
+}
+
+// keyFunc returns the verification key named by the "kid" header.
+// The algorithm has to be the one of the key, so that e.g. an RSA public key is never used as an HMAC secret.
+func (s *Signer) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm() {
+		return nil, errors.New("algorithm does not match the key")
+	}
+	return key.verifyKey, nil
+}
@@ -0,0 +1,171 @@
+package accesstoken_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/infrastructure/accesstoken"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testIssuer = "test"
+
+var testSecret = []byte("test-secret-of-at-least-32-bytes!")
+
+func testClaims(ttl time.Duration) usecase.AccessTokenClaims {
+	now := time.Now()
+	return usecase.AccessTokenClaims{UserID: 1, IssuedAt: now, ExpiresAt: now.Add(ttl)}
+}
+
+func encodePEM(t *testing.T, blockType string, der []byte, err error) []byte {
+	t.Helper()
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der})
+}
+
+func testKeys(t *testing.T) map[string]accesstoken.Key {
+	t.Helper()
+
+	hmacKey, err := accesstoken.NewHMACKey("hs", testSecret)
+	require.NoError(t, err)
+
+	rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(rsaPrivateKey)
+	rsaKey, err := accesstoken.ParseKey("rs", accesstoken.AlgorithmRS256, encodePEM(t, "PRIVATE KEY", der, err))
+	require.NoError(t, err)
+
+	_, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err = x509.MarshalPKCS8PrivateKey(edPrivateKey)
+	edKey, err := accesstoken.ParseKey("ed", accesstoken.AlgorithmEdDSA, encodePEM(t, "PRIVATE KEY", der, err))
+	require.NoError(t, err)
+
+	return map[string]accesstoken.Key{"hs": hmacKey, "rs": rsaKey, "ed": edKey}
+}
+
+func TestSigner(t *testing.T) {
+	keys := testKeys(t)
+
+	for _, kid := range []string{"hs", "rs", "ed"} {
+		t.Run(keys[kid].Algorithm(), func(t *testing.T) {
+			s, err := accesstoken.NewSigner(testIssuer, kid, keys[kid])
+			require.NoError(t, err)
+
+			token, err := s.Sign(testClaims(time.Minute))
+			require.NoError(t, err)
+
+			claims, err := s.Verify(token)
+			if assert.NoError(t, err) {
+				assert.Equal(t, domain.UserID(1), claims.UserID)
+			}
+
+			// the claims of another token with this signature
+			otherClaims := testClaims(time.Minute)
+			otherClaims.UserID = 2
+			other, err := s.Sign(otherClaims)
+			require.NoError(t, err)
+			tampered := other[:strings.LastIndex(other, ".")] + token[strings.LastIndex(token, "."):]
+			_, err = s.Verify(tampered)
+			assert.ErrorIs(t, err, usecase.ErrInvalidAccessToken)
+		})
+	}
+
+	t.Run("Key rotation", func(t *testing.T) {
+		old, err := accesstoken.NewSigner(testIssuer, "hs", keys["hs"])
+		require.NoError(t, err)
+		oldToken, err := old.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		// the old key still verifies after the new one has become current
+		rotated, err := accesstoken.NewSigner(testIssuer, "ed", keys["ed"], keys["hs"])
+		require.NoError(t, err)
+		_, err = rotated.Verify(oldToken)
+		assert.NoError(t, err)
+
+		// and is rejected once it has been removed
+		retired, err := accesstoken.NewSigner(testIssuer, "ed", keys["ed"])
+		require.NoError(t, err)
+		_, err = retired.Verify(oldToken)
+		assert.ErrorIs(t, err, usecase.ErrInvalidAccessToken)
+	})
+
+	t.Run("Verify only key", func(t *testing.T) {
+		_, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		der, err := x509.MarshalPKCS8PrivateKey(edPrivateKey)
+		privateKey, err := accesstoken.ParseKey("old", accesstoken.AlgorithmEdDSA, encodePEM(t, "PRIVATE KEY", der, err))
+		require.NoError(t, err)
+		der, err = x509.MarshalPKIXPublicKey(edPrivateKey.Public())
+		publicKey, err := accesstoken.ParseKey("old", accesstoken.AlgorithmEdDSA, encodePEM(t, "PUBLIC KEY", der, err))
+		require.NoError(t, err)
+		assert.False(t, publicKey.CanSign())
+
+		old, err := accesstoken.NewSigner(testIssuer, "old", privateKey)
+		require.NoError(t, err)
+		token, err := old.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		// a retired key kept as its public key cannot sign, but still verifies
+		_, err = accesstoken.NewSigner(testIssuer, "old", publicKey)
+		assert.ErrorIs(t, err, accesstoken.ErrVerifyOnlyKey)
+
+		s, err := accesstoken.NewSigner(testIssuer, "hs", keys["hs"], publicKey)
+		require.NoError(t, err)
+		_, err = s.Verify(token)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Invalid tokens", func(t *testing.T) {
+		s, err := accesstoken.NewSigner(testIssuer, "hs", keys["hs"], keys["rs"])
+		require.NoError(t, err)
+
+		expired, err := s.Sign(testClaims(-time.Minute))
+		require.NoError(t, err)
+
+		other, err := accesstoken.NewSigner("other", "hs", keys["hs"])
+		require.NoError(t, err)
+		otherIssuer, err := other.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		// an HS256 token signed under the kid of the RSA key
+		rsKid, err := accesstoken.NewHMACKey("rs", testSecret)
+		require.NoError(t, err)
+		confused, err := accesstoken.NewSigner(testIssuer, "rs", rsKid)
+		require.NoError(t, err)
+		algConfusion, err := confused.Sign(testClaims(time.Minute))
+		require.NoError(t, err)
+
+		cases := []struct {
+			name  string
+			token string
+		}{
+			{name: "expired", token: expired},
+			{name: "other issuer", token: otherIssuer},
+			{name: "algorithm of another key", token: algConfusion},
+			{name: "malformed", token: "not-a-token"},
+			{name: "none algorithm", token: strings.Join([]string{"eyJhbGciOiJub25lIiwia2lkIjoiaHMifQ", "eyJzdWIiOiIxIn0", ""}, ".")},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := s.Verify(tt.token)
+				assert.ErrorIs(t, err, usecase.ErrInvalidAccessToken)
+			})
+		}
+	})
+
+	t.Run("Short HMAC secret", func(t *testing.T) {
+		_, err := accesstoken.NewHMACKey("hs", []byte("short"))
+		assert.Error(t, err)
+	})
+}
@@ -1,55 +1,188 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net"
 	"net/http"
+	"time"
 
-	"github.com/go-playground/validator/v10"
-	"github.com/gorilla/sessions"
 	"github.com/labstack/echo-contrib/session"
 	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
 	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/infrastructure/loginattempt"
+	"github.com/ricky2122/go-echo-example/infrastructure/password"
+	"github.com/ricky2122/go-echo-example/infrastructure/ratelimit"
 	"github.com/ricky2122/go-echo-example/infrastructure/repository"
+	"github.com/ricky2122/go-echo-example/infrastructure/sessionstore"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
 	"github.com/ricky2122/go-echo-example/usecase"
 	"github.com/uptrace/bun"
 )
 
-type CustomValidator struct {
-	validator *validator.Validate
-}
+type RouterConfig struct {
+	SessionStore *sessionstore.Store
+	Mailer       usecase.Mailer
+
+	// User configures the email verification mails
+	User usecase.UserUseCaseConfig
+	// Auth configures whether unverified users may log in and how failed logins are throttled
+	Auth usecase.AuthUseCaseConfig
+	// LoginAttempts counts the failed logins; nil keeps them in the database
+	LoginAttempts usecase.ILoginAttemptRepository
+	// PasswordReset configures the password reset mails
+	PasswordReset usecase.PasswordUseCaseConfig
+
+	// TokenSigner signs the bearer access tokens; nil disables POST /token and bearer authentication
+	TokenSigner usecase.AccessTokenSigner
+	// Token configures the lifetime of the bearer tokens
+	Token usecase.TokenUseCaseConfig
+
+	// RateLimitStore counts the requests of the rate limits; nil keeps them in the database
+	RateLimitStore controller.RateLimitStore
+	// RateLimitFailOpen lets the requests through while the store fails, instead of rejecting them with 503
+	RateLimitFailOpen bool
+	// RateLimits are the policies of the route groups; a zero policy does not limit the group
+	RateLimits RateLimitPolicies
 
-func NewCustomValidator() *CustomValidator {
-	return &CustomValidator{validator: validator.New()}
+	// TrustedProxies are the proxies whose X-Forwarded-For header tells the client IP.
+	// Without them the client IP is the remote address of the connection.
+	TrustedProxies []*net.IPNet
+
+	// RequestTimeout bounds the request context passed down to the database (zero means no timeout)
+	RequestTimeout time.Duration
 }
 
-func (cv *CustomValidator) Validate(i interface{}) error {
-	if err := cv.validator.Struct(i); err != nil {
-		// Optionally, you could return the error to give each route more control over the status code
-		return echo.NewHTTPError(http.StatusBadRequest, err.Error())
-	}
-	return nil
+// RateLimitPolicies are the rate limits of the route groups.
+type RateLimitPolicies struct {
+	// Signup limits POST /signup
+	Signup controller.RateLimitPolicy
+	// Auth limits the routes that log in or send mails without a login
+	Auth controller.RateLimitPolicy
+	// API limits the routes that require a login, after the login user is known
+	API controller.RateLimitPolicy
 }
 
-func NewRouter(db *bun.DB) *echo.Echo {
+func NewRouter(db *bun.DB, conf RouterConfig) (*echo.Echo, error) {
 	e := echo.New()
+	e.HTTPErrorHandler = controller.HTTPErrorHandler
+	e.IPExtractor = newIPExtractor(conf.TrustedProxies)
+
+	// request id, returned in the X-Request-Id header and in error responses
+	e.Use(middleware.RequestID())
+
+	// request timeout
+	if conf.RequestTimeout > 0 {
+		e.Use(middleware.ContextTimeoutWithConfig(middleware.ContextTimeoutConfig{
+			Timeout: conf.RequestTimeout,
+			// only a deadline is a timeout; the other errors keep their own response
+			ErrorHandler: func(err error, _ echo.Context) error {
+				if !errors.Is(err, context.DeadlineExceeded) {
+					return err
+				}
+				return controller.NewHTTPError(http.StatusServiceUnavailable, controller.CodeRequestTimeout, "request timeout").WithInternal(err)
+			},
+		}))
+	}
 
 	// session
-	e.Use(session.Middleware(sessions.NewCookieStore([]byte("secret"))))
+	e.Use(session.Middleware(conf.SessionStore))
 
 	// set validator
-	e.Validator = &CustomValidator{validator: validator.New()}
+	e.Validator = validator.NewCustomValidator()
+
+	ph := password.NewDefaultHasher()
 
 	ur := repository.NewUserRepository(db)
-	uu := usecase.NewUserUseCase(ur)
+	rr := repository.NewRoleRepository(db)
+	tr := repository.NewUserTokenRepository(db)
+	rtr := repository.NewRefreshTokenRepository(db)
+	sr := usecase.SessionRepositories{conf.SessionStore, rtr}
+	uu := usecase.NewUserUseCase(ur, tr, sr, ph, conf.Mailer, conf.User)
 	uc := controller.NewUserController(uu)
 
-	au := usecase.NewAuthUseCase()
+	lar := conf.LoginAttempts
+	if lar == nil {
+		lar = loginattempt.NewPostgresStore(db)
+	}
+	au, err := usecase.NewAuthUseCase(ur, ph, lar, repository.NewAuditLogRepository(db), conf.Auth)
+	if err != nil {
+		return nil, err
+	}
 	ac := controller.NewAuthController(au)
 
-	e.POST("/signup", uc.SignUp)
-	e.POST("/login", ac.Login)
+	// an untyped nil, so that the middleware sees that bearer tokens are disabled
+	var tu controller.ITokenUseCase
+	if conf.TokenSigner != nil {
+		tu = usecase.NewTokenUseCase(au, ur, rtr, sr, conf.TokenSigner, conf.Token)
+	}
+	tc := controller.NewTokenController(tu)
+	am := controller.NewAuthMiddleware(au, tu)
+
+	ru := usecase.NewRoleUseCase(ur, rr)
+	rc := controller.NewRoleController(ru)
+	rm := controller.NewAuthorizationMiddleware(ru)
+
+	pu := usecase.NewPasswordUseCase(ur, tr, sr, ph, conf.Mailer, conf.PasswordReset)
+	pc := controller.NewPasswordController(pu)
+
+	hc := controller.NewHealthController(db)
+
+	rls := conf.RateLimitStore
+	if rls == nil {
+		rls = ratelimit.NewPostgresStore(db)
+	}
+	rlm := controller.NewRateLimitMiddleware(rls, conf.RateLimitFailOpen)
+	signupLimit := rlm.Limit(conf.RateLimits.Signup)
+	authLimit := rlm.Limit(conf.RateLimits.Auth)
+	apiLimit := rlm.Limit(conf.RateLimits.API)
+
+	e.GET("/healthz", hc.Healthz)
+	e.POST("/signup", uc.SignUp, signupLimit)
+	e.POST("/login", ac.Login, authLimit)
 	e.POST("/logout", ac.Logout)
+	if tu != nil {
+		e.POST("/token", tc.IssueToken, authLimit)
+		e.POST("/token/revoke", tc.RevokeToken, authLimit)
+	}
+	e.GET("/verify-email", uc.VerifyEmail)
+	e.POST("/verify-email/resend", uc.ResendVerificationEmail, authLimit)
+	e.POST("/password-reset", pc.RequestPasswordReset, authLimit)
+	e.POST("/password-reset/confirm", pc.ConfirmPasswordReset, authLimit)
+
+	e.GET("/me", uc.GetMe, am.RequireLogin, apiLimit)
+	if tu != nil {
+		e.GET("/me/sessions", tc.GetSessions, am.RequireLogin, apiLimit)
+		e.DELETE("/me/sessions/:id", tc.RevokeSession, am.RequireLogin, apiLimit)
+	}
+
+	users := e.Group("/users", am.RequireLogin, apiLimit)
+	users.POST("/me/password", pc.ChangePassword)
+	users.GET("/:id", uc.GetUser, rm.RequirePermission(domain.PermissionUsersRead))
+	users.GET("", uc.GetUsers, rm.RequirePermission(domain.PermissionUsersList))
+	users.PATCH("/:id", uc.UpdateUser)
+	users.DELETE("/:id", uc.DeleteUser)
 
-	e.GET("/users/:id", uc.GetUser)
-	e.GET("/users", uc.GetUsers)
-	return e
+	admin := e.Group("/admin", am.RequireLogin, apiLimit)
+	admin.POST("/users/:id/restore", uc.RestoreUser, rm.RequirePermission(domain.PermissionUsersRestore))
+	admin.GET("/users/:id/roles", rc.GetUserRoles, rm.RequirePermission(domain.PermissionRolesRead))
+	admin.PUT("/users/:id/roles/:role", rc.AssignRole, rm.RequirePermission(domain.PermissionRolesAssign))
+	admin.DELETE("/users/:id/roles/:role", rc.RevokeRole, rm.RequirePermission(domain.PermissionRolesAssign))
+
+	return e, nil
+}
+
+// newIPExtractor trusts X-Forwarded-For only when it is set by one of the proxies,
+// so that clients cannot choose the IP their failed logins are counted by.
+func newIPExtractor(trustedProxies []*net.IPNet) echo.IPExtractor {
+	if len(trustedProxies) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, proxy := range trustedProxies {
+		options = append(options, echo.TrustIPRange(proxy))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
 }
@@ -0,0 +1,59 @@
+package api_test
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"github.com/ricky2122/go-echo-example/infrastructure/loginattempt"
+	"github.com/ricky2122/go-echo-example/infrastructure/ratelimit"
+	"github.com/ricky2122/go-echo-example/infrastructure/sessionstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+func TestNewRouterRequestTimeout(t *testing.T) {
+	// the requests below fail before the database is used, so it is never connected
+	db := bun.NewDB(sql.OpenDB(pgdriver.NewConnector()), pgdialect.New())
+	defer db.Close()
+
+	e, err := api.NewRouter(db, api.RouterConfig{
+		SessionStore:   sessionstore.NewStore(db, []byte("0123456789abcdef0123456789abcdef")),
+		LoginAttempts:  loginattempt.NewMemoryStore(),
+		RateLimitStore: ratelimit.NewMemoryStore(),
+		RequestTimeout: 10 * time.Second,
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	cases := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+	}{
+		{name: "invalid request", method: http.MethodPost, target: "/login", body: `{`, wantStatus: http.StatusBadRequest},
+		{name: "unknown route", method: http.MethodGet, target: "/unknown", wantStatus: http.StatusNotFound},
+		{name: "no login", method: http.MethodGet, target: "/me", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantStatus, rec.Code, rec.Body.String())
+		})
+	}
+}
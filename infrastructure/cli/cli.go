@@ -0,0 +1,274 @@
+// Package cli implements the subcommands of the server binary.
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure"
+	"github.com/ricky2122/go-echo-example/infrastructure/accesstoken"
+	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"github.com/ricky2122/go-echo-example/infrastructure/config"
+	"github.com/ricky2122/go-echo-example/infrastructure/loginattempt"
+	"github.com/ricky2122/go-echo-example/infrastructure/mailer"
+	"github.com/ricky2122/go-echo-example/infrastructure/ratelimit"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/uptrace/bun"
+)
+
+const usage = `usage: %s [-config file] <command> [arguments]
+
+commands:
+  serve                 start the server (default)
+  migrate               apply, roll back, list or create database migrations
+  seed                  insert the development data
+  user                  create, list, disable users or reset their password
+  healthcheck           check that the server is healthy, for container health checks
+
+flags of a command are shown by "<command> -h", and fall back to the config and environment variables.
+`
+
+type app struct {
+	name       string
+	configPath string
+	in         io.Reader
+	out        io.Writer
+}
+
+// Run runs the command given by args, without the program name.
+func Run(ctx context.Context, args []string) error {
+	a := &app{name: filepath.Base(os.Args[0]), in: os.Stdin, out: os.Stdout}
+
+	fs := flag.NewFlagSet(a.name, flag.ContinueOnError)
+	fs.StringVar(&a.configPath, "config", os.Getenv("CONFIG_FILE"), "YAML config file (default $CONFIG_FILE)")
+	fs.Usage = a.usage
+	if err := fs.Parse(args); err != nil {
+		return ignoreHelp(err)
+	}
+
+	command, args := "serve", fs.Args()
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	return ignoreHelp(a.run(ctx, command, args))
+}
+
+func (a *app) run(ctx context.Context, command string, args []string) error {
+	switch command {
+	case "serve":
+		return a.serve(ctx, args)
+	case "migrate":
+		return a.migrate(ctx, args)
+	case "seed":
+		return a.seed(ctx, args)
+	case "user":
+		return a.user(ctx, args)
+	case "healthcheck":
+		return a.healthcheck(ctx, args)
+	case "help":
+		a.usage()
+		return nil
+	default:
+		a.usage()
+		return fmt.Errorf("unknown command %q", command)
+	}
+}
+
+func (a *app) usage() {
+	fmt.Fprintf(a.out, usage, a.name)
+}
+
+func (a *app) loadConfig() (*config.Config, error) {
+	conf, err := config.LoadFile(a.configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	return conf, nil
+}
+
+// loadConfigAndDB loads the config and opens the database, which the caller has to close.
+func (a *app) loadConfigAndDB(ctx context.Context) (*config.Config, *bun.DB, error) {
+	conf, err := a.loadConfig()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	db, err := infrastructure.NewDB(ctx, infrastructure.DBConfig{
+		Host:            conf.DB.Host,
+		Port:            conf.DB.Port,
+		DBName:          conf.DB.Name,
+		User:            conf.DB.User,
+		Password:        conf.DB.Password,
+		MaxOpenConns:    conf.DB.MaxOpenConns,
+		MaxIdleConns:    conf.DB.MaxIdleConns,
+		ConnMaxLifetime: conf.DB.ConnMaxLifetime,
+		ConnectTimeout:  conf.DB.ConnectTimeout,
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return conf, db, nil
+}
+
+func newMailer(conf *config.Config) usecase.Mailer {
+	if conf.Mail.Driver == "file" {
+		return mailer.NewFileMailer(conf.Mail.From, conf.Mail.Dir)
+	}
+	return mailer.NewLogMailer(conf.Mail.From)
+}
+
+func newUserUseCaseConfig(conf *config.Config) usecase.UserUseCaseConfig {
+	return usecase.UserUseCaseConfig{
+		VerifyEmailURL:            conf.EmailVerification.URL,
+		VerifyEmailTokenTTL:       conf.EmailVerification.TokenTTL,
+		VerifyEmailResendInterval: conf.EmailVerification.ResendInterval,
+	}
+}
+
+func newPasswordUseCaseConfig(conf *config.Config) usecase.PasswordUseCaseConfig {
+	return usecase.PasswordUseCaseConfig{
+		ResetURL:            conf.PasswordReset.URL,
+		ResetTokenTTL:       conf.PasswordReset.TokenTTL,
+		ResetResendInterval: conf.PasswordReset.ResendInterval,
+	}
+}
+
+// newAccessTokenSigner returns the signer of the configured token keys, or nil if there are none.
+func newAccessTokenSigner(conf *config.Config) (usecase.AccessTokenSigner, error) {
+	if len(conf.Token.Keys) == 0 {
+		return nil, nil
+	}
+
+	keys := make([]accesstoken.Key, 0, len(conf.Token.Keys))
+	for _, keyConf := range conf.Token.Keys {
+		data := []byte(keyConf.Secret)
+		if keyConf.KeyFile != "" {
+			var err error
+			if data, err = os.ReadFile(keyConf.KeyFile); err != nil {
+				return nil, fmt.Errorf("read token key %s: %w", keyConf.ID, err)
+			}
+		}
+		key, err := accesstoken.ParseKey(keyConf.ID, keyConf.Algorithm, data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	signingKey := conf.Token.SigningKey
+	if signingKey == "" {
+		signingKey = keys[0].ID
+	}
+	return accesstoken.NewSigner(conf.Token.Issuer, signingKey, keys...)
+}
+
+func newTokenUseCaseConfig(conf *config.Config) usecase.TokenUseCaseConfig {
+	return usecase.TokenUseCaseConfig{
+		AccessTokenTTL:  conf.Token.AccessTokenTTL,
+		RefreshTokenTTL: conf.Token.RefreshTokenTTL,
+	}
+}
+
+func newAuthUseCaseConfig(conf *config.Config) usecase.AuthUseCaseConfig {
+	return usecase.AuthUseCaseConfig{
+		RequireVerifiedEmail: conf.EmailVerification.Required,
+		Throttle: usecase.LoginThrottleConfig{
+			MaxFailuresPerUser: conf.Login.MaxFailuresPerUser,
+			MaxFailuresPerIP:   conf.Login.MaxFailuresPerIP,
+			FailureWindow:      conf.Login.FailureWindow,
+			LockoutDuration:    conf.Login.LockoutDuration,
+			BaseDelay:          conf.Login.BaseDelay,
+			MaxDelay:           conf.Login.MaxDelay,
+		},
+	}
+}
+
+// loginAttemptStore is a login attempt repository that forgets the keys without recent failures.
+type loginAttemptStore interface {
+	usecase.ILoginAttemptRepository
+	DeleteExpired(ctx context.Context, window time.Duration) (int64, error)
+}
+
+func newLoginAttemptStore(conf *config.Config, db *bun.DB) loginAttemptStore {
+	if conf.Login.Store == "memory" {
+		return loginattempt.NewMemoryStore()
+	}
+	return loginattempt.NewPostgresStore(db)
+}
+
+// rateLimitStore is a rate limit store that forgets the keys whose limit is fully available again.
+type rateLimitStore interface {
+	controller.RateLimitStore
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+func newRateLimitStore(conf *config.Config, db *bun.DB) rateLimitStore {
+	if conf.RateLimit.Store == "memory" {
+		return ratelimit.NewMemoryStore()
+	}
+	return ratelimit.NewPostgresStore(db)
+}
+
+func newRateLimitPolicies(conf *config.Config) api.RateLimitPolicies {
+	return api.RateLimitPolicies{
+		Signup: newRateLimitPolicy("signup", conf.RateLimit.Signup, conf.RateLimit.APIKeys),
+		Auth:   newRateLimitPolicy("auth", conf.RateLimit.Auth, conf.RateLimit.APIKeys),
+		API:    newRateLimitPolicy("api", conf.RateLimit.API, conf.RateLimit.APIKeys),
+	}
+}
+
+func newRateLimitPolicy(name string, policyConf config.RateLimitPolicyConfig, apiKeys []string) controller.RateLimitPolicy {
+	policy := controller.RateLimitPolicy{Name: name, Limit: policyConf.Limit, Window: policyConf.Window}
+	switch policyConf.Key {
+	case "user":
+		policy.Key = controller.RateLimitByUser
+	case "api_key":
+		policy.Key = controller.NewRateLimitByAPIKey(apiKeys)
+	default:
+		policy.Key = controller.RateLimitByIP
+	}
+	return policy
+}
+
+func newTrustedProxies(conf *config.Config) ([]*net.IPNet, error) {
+	proxies := make([]*net.IPNet, 0, len(conf.Server.TrustedProxies))
+	for _, proxy := range conf.Server.TrustedProxies {
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
+
+// newFlagSet returns a flag set printing its usage to the app output.
+func (a *app) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(a.out)
+	fs.Usage = func() {
+		fmt.Fprintf(a.out, "usage: %s %s\n", a.name, usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// ignoreHelp treats the help requested by -h as success.
+func ignoreHelp(err error) error {
+	if errors.Is(err, flag.ErrHelp) {
+		return nil
+	}
+	return err
+}
+
+// errUsage is returned for invalid arguments after the usage has been printed.
+var errUsage = errors.New("invalid arguments")
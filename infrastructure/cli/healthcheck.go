@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+)
+
+// healthcheck requests /healthz and fails unless the server answers 200, e.g. for a Docker HEALTHCHECK.
+func (a *app) healthcheck(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("healthcheck", "healthcheck [-url url] [-timeout duration]")
+	url := fs.String("url", "", "health check URL (default /healthz on server.addr, $SERVER_ADDR)")
+	timeout := fs.Duration("timeout", 5*time.Second, "timeout of the request")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// only the server address is needed from the config
+	if *url == "" {
+		conf, err := a.loadConfig()
+		if err != nil {
+			return err
+		}
+		*url = healthURL(conf.Server.Addr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, *url, nil)
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unhealthy: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unhealthy: %s", res.Status)
+	}
+	fmt.Fprintln(a.out, "healthy")
+	return nil
+}
+
+// healthURL returns the /healthz URL of the server listening on addr, e.g. ":1323" is "http://localhost:1323/healthz".
+func healthURL(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "http://" + addr + "/healthz"
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port) + "/healthz"
+}
@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/migrations"
+	"github.com/ricky2122/go-echo-example/infrastructure/seed"
+	"github.com/uptrace/bun/migrate"
+)
+
+// migrate applies, rolls back, lists or creates migrations.
+func (a *app) migrate(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("migrate", "migrate up|down|status|create [-go] <name>")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// creating files needs no database
+	if fs.Arg(0) == "create" {
+		return a.createMigration(ctx, fs.Args()[1:])
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	_, db, err := a.loadConfigAndDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	m := migrations.NewMigrator(db)
+	switch fs.Arg(0) {
+	case "up":
+		group, err := migrations.Up(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to migrate: %w", err)
+		}
+		if group.IsZero() {
+			log.Printf("No new migrations to run (database is up to date)")
+			return nil
+		}
+		log.Printf("Migrated to %s", group)
+	case "down":
+		group, err := migrations.Down(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to roll back: %w", err)
+		}
+		if group.IsZero() {
+			log.Printf("No groups to roll back")
+			return nil
+		}
+		log.Printf("Rolled back %s", group)
+	case "status":
+		ms, err := migrations.Status(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to get migration status: %w", err)
+		}
+		for _, mig := range ms {
+			status := "pending"
+			if mig.IsApplied() {
+				status = "applied at " + mig.MigratedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Fprintf(a.out, "%s\t%s\n", mig, status)
+		}
+	default:
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func (a *app) createMigration(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("migrate create", "migrate create [-go] <name>")
+	goMigration := fs.Bool("go", false, "create a Go migration instead of SQL files")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errUsage
+	}
+
+	// files are written next to the migrations package sources
+	m := migrations.NewMigrator(nil)
+	var files []*migrate.MigrationFile
+	if *goMigration {
+		file, err := m.CreateGoMigration(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		files = append(files, file)
+	} else {
+		var err error
+		files, err = m.CreateTxSQLMigrations(ctx, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, file := range files {
+		log.Printf("Created migration %s", file.Path)
+	}
+	return nil
+}
+
+// seed inserts the development data, after the migrations have been applied.
+func (a *app) seed(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("seed", "seed")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	_, db, err := a.loadConfigAndDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := seed.Dev(ctx, db); err != nil {
+		return fmt.Errorf("failed to seed: %w", err)
+	}
+	log.Printf("Seeded development data")
+	return nil
+}
@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"github.com/ricky2122/go-echo-example/infrastructure/lifecycle"
+	"github.com/ricky2122/go-echo-example/infrastructure/password"
+	"github.com/ricky2122/go-echo-example/infrastructure/repository"
+	"github.com/ricky2122/go-echo-example/infrastructure/sessionstore"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+// serve runs the server until ctx is canceled, then shuts it down gracefully.
+func (a *app) serve(ctx context.Context, args []string) error {
+	fs := a.newFlagSet("serve", "serve [-addr address]")
+	addr := fs.String("addr", "", "address to listen on (default server.addr, $SERVER_ADDR)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return errUsage
+	}
+
+	conf, db, err := a.loadConfigAndDB(ctx)
+	if err != nil {
+		return err
+	}
+	if *addr != "" {
+		conf.Server.Addr = *addr
+	}
+	tokenSigner, err := newAccessTokenSigner(conf)
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("failed to load token keys: %w", err)
+	}
+	trustedProxies, err := newTrustedProxies(conf)
+	if err != nil {
+		_ = db.Close()
+		return fmt.Errorf("failed to parse trusted proxies: %w", err)
+	}
+
+	lc := lifecycle.New()
+	lc.OnShutdown("db", func(context.Context) error {
+		return db.Close()
+	})
+
+	sessionStore := sessionstore.NewStore(db, []byte(conf.Session.Key))
+	sessionStore.UserIDKey = controller.SessionUserIDKey
+	lc.Every("session reaper", time.Hour, func(ctx context.Context) error {
+		_, err := sessionStore.DeleteExpired(ctx)
+		return err
+	})
+
+	refreshTokenRepository := repository.NewRefreshTokenRepository(db)
+	lc.Every("refresh token reaper", time.Hour, func(ctx context.Context) error {
+		_, err := refreshTokenRepository.DeleteExpired(ctx)
+		return err
+	})
+
+	loginAttempts := newLoginAttemptStore(conf, db)
+	lc.Every("login attempt reaper", time.Hour, func(ctx context.Context) error {
+		_, err := loginAttempts.DeleteExpired(ctx, conf.Login.FailureWindow)
+		return err
+	})
+
+	rateLimits := newRateLimitStore(conf, db)
+	lc.Every("rate limit reaper", time.Hour, func(ctx context.Context) error {
+		_, err := rateLimits.DeleteExpired(ctx)
+		return err
+	})
+
+	m := newMailer(conf)
+	userConf := newUserUseCaseConfig(conf)
+
+	uu := usecase.NewUserUseCase(
+		repository.NewUserRepository(db),
+		repository.NewUserTokenRepository(db),
+		usecase.SessionRepositories{sessionStore, repository.NewRefreshTokenRepository(db)},
+		password.NewDefaultHasher(),
+		m,
+		userConf,
+	)
+	lc.Every("user purger", conf.User.PurgeInterval, func(ctx context.Context) error {
+		_, err := uu.PurgeDeletedUsers(ctx, conf.User.Retention)
+		return err
+	})
+
+	router, err := api.NewRouter(db, api.RouterConfig{
+		SessionStore:      sessionStore,
+		Mailer:            m,
+		User:              userConf,
+		Auth:              newAuthUseCaseConfig(conf),
+		LoginAttempts:     loginAttempts,
+		PasswordReset:     newPasswordUseCaseConfig(conf),
+		TokenSigner:       tokenSigner,
+		Token:             newTokenUseCaseConfig(conf),
+		RateLimitStore:    rateLimits,
+		RateLimitFailOpen: conf.RateLimit.FailOpen,
+		RateLimits:        newRateLimitPolicies(conf),
+		TrustedProxies:    trustedProxies,
+		RequestTimeout:    conf.Server.RequestTimeout,
+	})
+	if err != nil {
+		_ = lc.Shutdown(context.Background())
+		return fmt.Errorf("failed to create router: %w", err)
+	}
+	lc.OnShutdown("server", router.Shutdown)
+
+	// start server
+	serverErr := make(chan error, 1)
+	go func() {
+		serverErr <- router.Start(conf.Server.Addr)
+	}()
+
+	// wait for a signal or a server failure
+	var errs []error
+	select {
+	case <-ctx.Done():
+		log.Printf("Shutting down")
+	case err := <-serverErr:
+		if !errors.Is(err, http.ErrServerClosed) {
+			errs = append(errs, fmt.Errorf("server stopped: %w", err))
+		}
+	}
+
+	// drain in-flight requests, then release resources
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), conf.Server.ShutdownTimeout)
+	defer cancel()
+	if err := lc.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("failed to shut down gracefully: %w", err))
+	}
+
+	return errors.Join(errs...)
+}
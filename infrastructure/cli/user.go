@@ -0,0 +1,360 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/infrastructure/password"
+	"github.com/ricky2122/go-echo-example/infrastructure/repository"
+	"github.com/ricky2122/go-echo-example/infrastructure/sessionstore"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+const userUsage = "user create|list|disable|reset-password|assign-role|revoke-role [flags] [id] [role]"
+
+type IUserUseCase interface {
+	SignUp(ctx context.Context, input usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error)
+	GetUsers(ctx context.Context, input usecase.GetUsersUseCaseInput) (*usecase.GetUsersUseCaseOutput, error)
+	DisableUser(ctx context.Context, input usecase.DisableUserUseCaseInput) error
+}
+
+type IPasswordUseCase interface {
+	ResetPassword(ctx context.Context, input usecase.ResetPasswordUseCaseInput) error
+}
+
+type IRoleUseCase interface {
+	AssignRole(ctx context.Context, input usecase.AssignRoleUseCaseInput) error
+	RevokeRole(ctx context.Context, input usecase.RevokeRoleUseCaseInput) error
+}
+
+// the flags are validated with the rules of the API requests
+type createUserFlags struct {
+	Name     string `json:"name" validate:"required,username"`
+	Email    string `json:"email" validate:"required,email,max=64"`
+	BirthDay string `json:"birth-day" validate:"required,birthday"`
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+type listUsersFlags struct {
+	Limit       int    `json:"limit" validate:"omitempty,gte=1,lte=100"`
+	Sort        string `json:"sort" validate:"omitempty,oneof=id -id name -name birth_day -birth_day"`
+	NamePrefix  string `json:"name-prefix"`
+	EmailDomain string `json:"email-domain"`
+}
+
+type resetPasswordFlags struct {
+	Password string `json:"password" validate:"required,min=8,max=72"`
+}
+
+// UserCommands administers users through the same use cases as the API.
+type UserCommands struct {
+	uu        IUserUseCase
+	pu        IPasswordUseCase
+	ru        IRoleUseCase
+	in        io.Reader
+	out       io.Writer
+	validator *validator.CustomValidator
+}
+
+func NewUserCommands(uu IUserUseCase, pu IPasswordUseCase, ru IRoleUseCase, in io.Reader, out io.Writer) *UserCommands {
+	return &UserCommands{uu: uu, pu: pu, ru: ru, in: in, out: out, validator: validator.NewCustomValidator()}
+}
+
+func (a *app) user(ctx context.Context, args []string) error {
+	conf, db, err := a.loadConfigAndDB(ctx)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ph := password.NewDefaultHasher()
+	m := newMailer(conf)
+	ur := repository.NewUserRepository(db)
+	rr := repository.NewRoleRepository(db)
+	tr := repository.NewUserTokenRepository(db)
+	sr := usecase.SessionRepositories{
+		sessionstore.NewStore(db, []byte(conf.Session.Key)),
+		repository.NewRefreshTokenRepository(db),
+	}
+
+	uu := usecase.NewUserUseCase(ur, tr, sr, ph, m, newUserUseCaseConfig(conf))
+	pu := usecase.NewPasswordUseCase(ur, tr, sr, ph, m, newPasswordUseCaseConfig(conf))
+	ru := usecase.NewRoleUseCase(ur, rr)
+
+	return NewUserCommands(uu, pu, ru, a.in, a.out).Run(ctx, args)
+}
+
+// Run runs the user subcommand given by args.
+func (uc *UserCommands) Run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(uc.out, "usage: %s\n", userUsage)
+		return errUsage
+	}
+
+	switch args[0] {
+	case "create":
+		return uc.create(ctx, args[1:])
+	case "list":
+		return uc.list(ctx, args[1:])
+	case "disable":
+		return uc.disable(ctx, args[1:])
+	case "reset-password":
+		return uc.resetPassword(ctx, args[1:])
+	case "assign-role":
+		return uc.assignRole(ctx, args[1:])
+	case "revoke-role":
+		return uc.revokeRole(ctx, args[1:])
+	default:
+		fmt.Fprintf(uc.out, "usage: %s\n", userUsage)
+		return errUsage
+	}
+}
+
+func (uc *UserCommands) create(ctx context.Context, args []string) error {
+	// parse flags
+	f := createUserFlags{}
+	fs := uc.newFlagSet("create", "user create -name <name> -email <email> -birth-day <yyyy-mm-dd> [-password <password>]")
+	fs.StringVar(&f.Name, "name", "", "user name")
+	fs.StringVar(&f.Email, "email", "", "email address")
+	fs.StringVar(&f.BirthDay, "birth-day", "", "birth day (yyyy-mm-dd)")
+	fs.StringVar(&f.Password, "password", "", "password (default: read from stdin)")
+	if err := uc.parse(fs, args, 0); err != nil {
+		return err
+	}
+	if f.Password == "" {
+		f.Password = uc.readPassword()
+	}
+
+	// validate
+	if err := uc.validate(&f); err != nil {
+		return err
+	}
+	birthDay, _ := time.Parse(domain.BirthDayLayout, f.BirthDay)
+
+	// sign up usecase (mails the verification link like a sign-up through the API)
+	input := usecase.SignUpUseCaseInput{
+		Name:     f.Name,
+		Password: f.Password,
+		Email:    f.Email,
+		BirthDay: birthDay,
+	}
+	output, err := uc.uu.SignUp(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "created user %d (%s)\n", output.ID, output.Name)
+	return nil
+}
+
+func (uc *UserCommands) list(ctx context.Context, args []string) error {
+	// parse flags
+	f := listUsersFlags{}
+	var cursor string
+	fs := uc.newFlagSet("list", "user list [-limit n] [-sort key] [-cursor cursor] [-name-prefix prefix] [-email-domain domain]")
+	fs.IntVar(&f.Limit, "limit", usecase.DefaultUsersLimit, "number of users per page (1-100)")
+	fs.StringVar(&f.Sort, "sort", "id", "sort key: id, name or birth_day, prefixed with - for descending order")
+	fs.StringVar(&cursor, "cursor", "", "next cursor printed by the previous page")
+	fs.StringVar(&f.NamePrefix, "name-prefix", "", "only users whose name starts with the prefix")
+	fs.StringVar(&f.EmailDomain, "email-domain", "", "only users whose email has the domain")
+	if err := uc.parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	// validate
+	if err := uc.validate(&f); err != nil {
+		return err
+	}
+	sortKey, desc := strings.CutPrefix(f.Sort, "-")
+
+	// get users usecase
+	input := usecase.GetUsersUseCaseInput{
+		Limit:   f.Limit,
+		Cursor:  cursor,
+		SortKey: usecase.UserSortKey(sortKey),
+		Desc:    desc,
+		Filter: usecase.UserFilter{
+			NamePrefix:  f.NamePrefix,
+			EmailDomain: f.EmailDomain,
+		},
+	}
+	output, err := uc.uu.GetUsers(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	// print users
+	w := tabwriter.NewWriter(uc.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tNAME\tEMAIL\tBIRTH_DAY\tSTATUS")
+	for _, user := range output.Users {
+		status := "active"
+		if user.Disabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\n", user.ID, user.Name, user.Email, user.BirthDay, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "total: %d\n", output.TotalCount)
+	if output.NextCursor != "" {
+		fmt.Fprintf(uc.out, "next cursor: %s\n", output.NextCursor)
+	}
+	return nil
+}
+
+func (uc *UserCommands) disable(ctx context.Context, args []string) error {
+	// parse flags
+	fs := uc.newFlagSet("disable", "user disable <id>")
+	if err := uc.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// disable user usecase
+	if err := uc.uu.DisableUser(ctx, usecase.DisableUserUseCaseInput{ID: id}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "disabled user %d\n", id)
+	return nil
+}
+
+func (uc *UserCommands) resetPassword(ctx context.Context, args []string) error {
+	// parse flags
+	f := resetPasswordFlags{}
+	fs := uc.newFlagSet("reset-password", "user reset-password [-password <password>] <id>")
+	fs.StringVar(&f.Password, "password", "", "new password (default: read from stdin)")
+	if err := uc.parse(fs, args, 1); err != nil {
+		return err
+	}
+	id, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if f.Password == "" {
+		f.Password = uc.readPassword()
+	}
+
+	// validate
+	if err := uc.validate(&f); err != nil {
+		return err
+	}
+
+	// reset password usecase (logs the user out of every session)
+	input := usecase.ResetPasswordUseCaseInput{UserID: id, NewPassword: f.Password}
+	if err := uc.pu.ResetPassword(ctx, input); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "reset the password of user %d\n", id)
+	return nil
+}
+
+func (uc *UserCommands) assignRole(ctx context.Context, args []string) error {
+	// parse flags
+	fs := uc.newFlagSet("assign-role", "user assign-role <id> <role>")
+	if err := uc.parse(fs, args, 2); err != nil {
+		return err
+	}
+	id, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// assign role usecase
+	input := usecase.AssignRoleUseCaseInput{UserID: id, Role: fs.Arg(1)}
+	if err := uc.ru.AssignRole(ctx, input); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "assigned role %s to user %d\n", fs.Arg(1), id)
+	return nil
+}
+
+func (uc *UserCommands) revokeRole(ctx context.Context, args []string) error {
+	// parse flags
+	fs := uc.newFlagSet("revoke-role", "user revoke-role <id> <role>")
+	if err := uc.parse(fs, args, 2); err != nil {
+		return err
+	}
+	id, err := parseUserID(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	// revoke role usecase
+	input := usecase.RevokeRoleUseCaseInput{UserID: id, Role: fs.Arg(1)}
+	if err := uc.ru.RevokeRole(ctx, input); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(uc.out, "revoked role %s from user %d\n", fs.Arg(1), id)
+	return nil
+}
+
+func (uc *UserCommands) newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(uc.out)
+	fs.Usage = func() {
+		fmt.Fprintf(uc.out, "usage: %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses the flags, which have to be followed by nargs arguments.
+func (uc *UserCommands) parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != nargs {
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// validate returns the messages of the failed rules as one error.
+func (uc *UserCommands) validate(flags any) error {
+	err := uc.validator.Validate(flags)
+	var ve *validator.ValidationError
+	if !errors.As(err, &ve) {
+		return err
+	}
+
+	fields := ve.FieldErrors("")
+	messages := make([]string, 0, len(fields))
+	for _, fe := range fields {
+		messages = append(messages, fe.Message)
+	}
+	return fmt.Errorf("%w: %s", errUsage, strings.Join(messages, ", "))
+}
+
+// readPassword reads the first line of the input, so that the password does not appear in the shell history.
+func (uc *UserCommands) readPassword() string {
+	line, _ := bufio.NewReader(uc.in).ReadString('\n')
+	return strings.TrimRight(line, "\r\n")
+}
+
+func parseUserID(s string) (int, error) {
+	id, err := strconv.Atoi(s)
+	if err != nil || id < 1 {
+		return 0, fmt.Errorf("%w: invalid user id %q", errUsage, s)
+	}
+	return id, nil
+}
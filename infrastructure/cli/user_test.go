@@ -0,0 +1,229 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/cli"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubUserUseCase struct {
+	signUpInput   usecase.SignUpUseCaseInput
+	getUsersInput usecase.GetUsersUseCaseInput
+	disabledID    int
+	err           error
+}
+
+func (s *TestStubUserUseCase) SignUp(_ context.Context, input usecase.SignUpUseCaseInput) (*usecase.SignUpUseCaseOutput, error) {
+	s.signUpInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &usecase.SignUpUseCaseOutput{ID: 1, Name: input.Name}, nil
+}
+
+func (s *TestStubUserUseCase) GetUsers(_ context.Context, input usecase.GetUsersUseCaseInput) (*usecase.GetUsersUseCaseOutput, error) {
+	s.getUsersInput = input
+	if s.err != nil {
+		return nil, s.err
+	}
+	output := &usecase.GetUsersUseCaseOutput{
+		Users: []usecase.GetUserUseCaseOutput{
+			{ID: 1, Name: "test01", Email: "test01@test.com", BirthDay: "2001-01-01"},
+			{ID: 2, Name: "test02", Email: "test02@test.com", BirthDay: "2002-01-01", Disabled: true},
+		},
+		NextCursor: "next",
+		TotalCount: 3,
+	}
+	return output, nil
+}
+
+func (s *TestStubUserUseCase) DisableUser(_ context.Context, input usecase.DisableUserUseCaseInput) error {
+	s.disabledID = input.ID
+	return s.err
+}
+
+type TestStubRoleUseCase struct {
+	assignInput usecase.AssignRoleUseCaseInput
+	revokeInput usecase.RevokeRoleUseCaseInput
+	err         error
+}
+
+func (s *TestStubRoleUseCase) AssignRole(_ context.Context, input usecase.AssignRoleUseCaseInput) error {
+	s.assignInput = input
+	return s.err
+}
+
+func (s *TestStubRoleUseCase) RevokeRole(_ context.Context, input usecase.RevokeRoleUseCaseInput) error {
+	s.revokeInput = input
+	return s.err
+}
+
+type TestStubPasswordUseCase struct {
+	input usecase.ResetPasswordUseCaseInput
+	err   error
+}
+
+func (s *TestStubPasswordUseCase) ResetPassword(_ context.Context, input usecase.ResetPasswordUseCaseInput) error {
+	s.input = input
+	return s.err
+}
+
+func TestUserCreate(t *testing.T) {
+	t.Run("password from flag", func(t *testing.T) {
+		uu := &TestStubUserUseCase{}
+		out := &bytes.Buffer{}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), out)
+
+		args := []string{"create", "-name", "test01", "-email", "test01@test.com", "-birth-day", "2001-01-01", "-password", "password01"}
+		if assert.NoError(t, uc.Run(context.Background(), args)) {
+			assert.Equal(t, usecase.SignUpUseCaseInput{
+				Name:     "test01",
+				Password: "password01",
+				Email:    "test01@test.com",
+				BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			}, uu.signUpInput)
+			assert.Equal(t, "created user 1 (test01)\n", out.String())
+		}
+	})
+
+	t.Run("password from stdin", func(t *testing.T) {
+		uu := &TestStubUserUseCase{}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader("secret-password\n"), &bytes.Buffer{})
+
+		args := []string{"create", "-name", "test01", "-email", "test01@test.com", "-birth-day", "2001-01-01"}
+		if assert.NoError(t, uc.Run(context.Background(), args)) {
+			assert.Equal(t, "secret-password", uu.signUpInput.Password)
+		}
+	})
+
+	t.Run("invalid flags", func(t *testing.T) {
+		uu := &TestStubUserUseCase{}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		args := []string{"create", "-name", "test 01", "-email", "test01", "-birth-day", "2001-01-01", "-password", "short"}
+		err := uc.Run(context.Background(), args)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "name")
+			assert.Contains(t, err.Error(), "email")
+			assert.Contains(t, err.Error(), "password")
+		}
+		assert.Empty(t, uu.signUpInput.Name)
+	})
+
+	t.Run("user already exists", func(t *testing.T) {
+		uu := &TestStubUserUseCase{err: usecase.ErrUserAlreadyExists}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		args := []string{"create", "-name", "test01", "-email", "test01@test.com", "-birth-day", "2001-01-01", "-password", "password01"}
+		assert.ErrorIs(t, uc.Run(context.Background(), args), usecase.ErrUserAlreadyExists)
+	})
+}
+
+func TestUserList(t *testing.T) {
+	uu := &TestStubUserUseCase{}
+	out := &bytes.Buffer{}
+	uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), out)
+
+	args := []string{"list", "-limit", "2", "-sort", "-name", "-name-prefix", "test"}
+	if assert.NoError(t, uc.Run(context.Background(), args)) {
+		assert.Equal(t, usecase.GetUsersUseCaseInput{
+			Limit:   2,
+			SortKey: usecase.UserSortByName,
+			Desc:    true,
+			Filter:  usecase.UserFilter{NamePrefix: "test"},
+		}, uu.getUsersInput)
+		assert.Equal(t, `ID  NAME    EMAIL            BIRTH_DAY   STATUS
+1   test01  test01@test.com  2001-01-01  active
+2   test02  test02@test.com  2002-01-01  disabled
+total: 3
+next cursor: next
+`, out.String())
+	}
+
+	t.Run("invalid sort key", func(t *testing.T) {
+		err := uc.Run(context.Background(), []string{"list", "-sort", "email"})
+		assert.Error(t, err)
+	})
+}
+
+func TestUserDisable(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		uu := &TestStubUserUseCase{}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		if assert.NoError(t, uc.Run(context.Background(), []string{"disable", "2"})) {
+			assert.Equal(t, 2, uu.disabledID)
+		}
+	})
+
+	t.Run("invalid id", func(t *testing.T) {
+		uu := &TestStubUserUseCase{}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		assert.Error(t, uc.Run(context.Background(), []string{"disable", "abc"}))
+		assert.Error(t, uc.Run(context.Background(), []string{"disable"}))
+		assert.Zero(t, uu.disabledID)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		uu := &TestStubUserUseCase{err: usecase.ErrUserNotFound}
+		uc := cli.NewUserCommands(uu, &TestStubPasswordUseCase{}, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		assert.ErrorIs(t, uc.Run(context.Background(), []string{"disable", "2"}), usecase.ErrUserNotFound)
+	})
+}
+
+func TestUserResetPassword(t *testing.T) {
+	t.Run("password from stdin", func(t *testing.T) {
+		pu := &TestStubPasswordUseCase{}
+		uc := cli.NewUserCommands(&TestStubUserUseCase{}, pu, &TestStubRoleUseCase{}, strings.NewReader("new-password\n"), &bytes.Buffer{})
+
+		if assert.NoError(t, uc.Run(context.Background(), []string{"reset-password", "1"})) {
+			assert.Equal(t, usecase.ResetPasswordUseCaseInput{UserID: 1, NewPassword: "new-password"}, pu.input)
+		}
+	})
+
+	t.Run("password too short", func(t *testing.T) {
+		pu := &TestStubPasswordUseCase{}
+		uc := cli.NewUserCommands(&TestStubUserUseCase{}, pu, &TestStubRoleUseCase{}, strings.NewReader(""), &bytes.Buffer{})
+
+		assert.Error(t, uc.Run(context.Background(), []string{"reset-password", "-password", "short", "1"}))
+		assert.Zero(t, pu.input.UserID)
+	})
+}
+
+func TestUserRoles(t *testing.T) {
+	t.Run("assign role", func(t *testing.T) {
+		ru := &TestStubRoleUseCase{}
+		out := &bytes.Buffer{}
+		uc := cli.NewUserCommands(&TestStubUserUseCase{}, &TestStubPasswordUseCase{}, ru, strings.NewReader(""), out)
+
+		if assert.NoError(t, uc.Run(context.Background(), []string{"assign-role", "1", "admin"})) {
+			assert.Equal(t, usecase.AssignRoleUseCaseInput{UserID: 1, Role: "admin"}, ru.assignInput)
+			assert.Equal(t, "assigned role admin to user 1\n", out.String())
+		}
+	})
+
+	t.Run("revoke role", func(t *testing.T) {
+		ru := &TestStubRoleUseCase{}
+		uc := cli.NewUserCommands(&TestStubUserUseCase{}, &TestStubPasswordUseCase{}, ru, strings.NewReader(""), &bytes.Buffer{})
+
+		if assert.NoError(t, uc.Run(context.Background(), []string{"revoke-role", "1", "admin"})) {
+			assert.Equal(t, usecase.RevokeRoleUseCaseInput{UserID: 1, Role: "admin"}, ru.revokeInput)
+		}
+	})
+
+	t.Run("missing role", func(t *testing.T) {
+		ru := &TestStubRoleUseCase{}
+		uc := cli.NewUserCommands(&TestStubUserUseCase{}, &TestStubPasswordUseCase{}, ru, strings.NewReader(""), &bytes.Buffer{})
+
+		assert.Error(t, uc.Run(context.Background(), []string{"assign-role", "1"}))
+		assert.Zero(t, ru.assignInput.UserID)
+	})
+}
@@ -0,0 +1,589 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/accesstoken"
+	"gopkg.in/yaml.v3"
+)
+
+// minSessionKeyLength is the minimum length of the key used to sign session cookies.
+const minSessionKeyLength = 32
+
+type Config struct {
+	Server  ServerConfig  `yaml:"server"`
+	DB      DBConfig      `yaml:"db"`
+	Session SessionConfig `yaml:"session"`
+	User    UserConfig    `yaml:"user"`
+	Mail    MailConfig    `yaml:"mail"`
+	Login   LoginConfig   `yaml:"login"`
+
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+
+	EmailVerification EmailVerificationConfig `yaml:"email_verification"`
+	PasswordReset     PasswordResetConfig     `yaml:"password_reset"`
+	Token             TokenConfig             `yaml:"token"`
+}
+
+type ServerConfig struct {
+	Addr            string        `yaml:"addr"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	RequestTimeout  time.Duration `yaml:"request_timeout"`
+	// TrustedProxies are the CIDRs of the proxies whose X-Forwarded-For header tells the client IP
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DBConfig struct {
+	Host            string        `yaml:"host"`
+	Port            string        `yaml:"port"`
+	Name            string        `yaml:"name"`
+	User            string        `yaml:"user"`
+	Password        string        `yaml:"password"`
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnectTimeout  time.Duration `yaml:"connect_timeout"`
+}
+
+type SessionConfig struct {
+	Key string `yaml:"key"`
+}
+
+type UserConfig struct {
+	// deleted users are purged after the retention period
+	Retention     time.Duration `yaml:"retention"`
+	PurgeInterval time.Duration `yaml:"purge_interval"`
+}
+
+type MailConfig struct {
+	// Driver is "log" (write mails to the log) or "file" (write .eml files to Dir)
+	Driver string `yaml:"driver"`
+	Dir    string `yaml:"dir"`
+	From   string `yaml:"from"`
+}
+
+// LoginConfig throttles failed logins per user name and per client IP; a zero maximum disables the lockout.
+type LoginConfig struct {
+	// Store is "postgres" (shared by all processes) or "memory" (per process)
+	Store              string `yaml:"store"`
+	MaxFailuresPerUser int    `yaml:"max_failures_per_user"`
+	MaxFailuresPerIP   int    `yaml:"max_failures_per_ip"`
+	// FailureWindow is how long a failure counts towards the lockout
+	FailureWindow   time.Duration `yaml:"failure_window"`
+	LockoutDuration time.Duration `yaml:"lockout_duration"`
+	// each consecutive failure doubles the wait before the next attempt, from BaseDelay up to MaxDelay
+	BaseDelay time.Duration `yaml:"base_delay"`
+	MaxDelay  time.Duration `yaml:"max_delay"`
+}
+
+// RateLimitConfig limits the requests per route group.
+type RateLimitConfig struct {
+	// Store is "postgres" (shared by all processes) or "memory" (per process)
+	Store string `yaml:"store"`
+	// FailOpen lets the requests through while the store fails, instead of rejecting them with 503
+	FailOpen bool `yaml:"fail_open"`
+	// APIKeys are the keys counted on their own by the "api_key" policies
+	APIKeys []string `yaml:"api_keys"`
+	// Signup limits POST /signup
+	Signup RateLimitPolicyConfig `yaml:"signup"`
+	// Auth limits the routes that log in or send mails without a login
+	Auth RateLimitPolicyConfig `yaml:"auth"`
+	// API limits the routes that require a login
+	API RateLimitPolicyConfig `yaml:"api"`
+}
+
+// RateLimitPolicyConfig allows Limit requests per Window for each key; a zero Limit disables it.
+type RateLimitPolicyConfig struct {
+	Limit  int           `yaml:"limit"`
+	Window time.Duration `yaml:"window"`
+	// Key is "ip", "user" (the login user, else the IP) or "api_key" (the X-API-Key header if it is one of APIKeys, else the IP)
+	Key string `yaml:"key"`
+}
+
+type EmailVerificationConfig struct {
+	// URL is the page the verification token is sent to as the "token" query parameter
+	URL            string        `yaml:"url"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	ResendInterval time.Duration `yaml:"resend_interval"`
+	// Required rejects the login of users who have not verified their email
+	Required bool `yaml:"required"`
+}
+
+type PasswordResetConfig struct {
+	// URL is the page the reset token is sent to as the "token" query parameter
+	URL            string        `yaml:"url"`
+	TokenTTL       time.Duration `yaml:"token_ttl"`
+	ResendInterval time.Duration `yaml:"resend_interval"`
+}
+
+// TokenConfig configures the bearer tokens of POST /token, which is disabled when there are no keys.
+type TokenConfig struct {
+	Issuer          string        `yaml:"issuer"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl"`
+	// SigningKey is the ID of the key new access tokens are signed with (default: the first key)
+	SigningKey string `yaml:"signing_key"`
+	// Keys verify the access tokens; a rotated key is kept until the tokens signed with it have expired
+	Keys []TokenKeyConfig `yaml:"keys"`
+}
+
+type TokenKeyConfig struct {
+	// ID is sent as the "kid" header of the tokens
+	ID string `yaml:"id"`
+	// Algorithm is "HS256", "RS256" or "EdDSA"
+	Algorithm string `yaml:"algorithm"`
+	// Secret is the HS256 secret
+	Secret string `yaml:"secret"`
+	// KeyFile is the PEM private key of RS256 and EdDSA, or the public key of a key that only verifies
+	KeyFile string `yaml:"key_file"`
+}
+
+func defaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":1323",
+			ShutdownTimeout: 30 * time.Second,
+			RequestTimeout:  10 * time.Second,
+		},
+		DB: DBConfig{
+			Host:            "localhost",
+			Port:            "15432",
+			Name:            "echo_example",
+			User:            "root",
+			MaxOpenConns:    25,
+			MaxIdleConns:    25,
+			ConnMaxLifetime: 5 * time.Minute,
+			ConnectTimeout:  30 * time.Second,
+		},
+		User: UserConfig{
+			Retention:     30 * 24 * time.Hour,
+			PurgeInterval: time.Hour,
+		},
+		Mail: MailConfig{
+			Driver: "log",
+			Dir:    "mail",
+			From:   "no-reply@example.com",
+		},
+		Login: LoginConfig{
+			Store:              "postgres",
+			MaxFailuresPerUser: 5,
+			MaxFailuresPerIP:   50,
+			FailureWindow:      15 * time.Minute,
+			LockoutDuration:    15 * time.Minute,
+			BaseDelay:          time.Second,
+			MaxDelay:           30 * time.Second,
+		},
+		RateLimit: RateLimitConfig{
+			Store:    "postgres",
+			FailOpen: true,
+			Signup:   RateLimitPolicyConfig{Limit: 5, Window: time.Hour, Key: "ip"},
+			Auth:     RateLimitPolicyConfig{Limit: 30, Window: time.Minute, Key: "ip"},
+			API:      RateLimitPolicyConfig{Limit: 600, Window: time.Minute, Key: "user"},
+		},
+		EmailVerification: EmailVerificationConfig{
+			URL:            "http://localhost:1323/verify-email",
+			TokenTTL:       24 * time.Hour,
+			ResendInterval: time.Minute,
+		},
+		PasswordReset: PasswordResetConfig{
+			URL:            "http://localhost:1323/password-reset",
+			TokenTTL:       time.Hour,
+			ResendInterval: time.Minute,
+		},
+		Token: TokenConfig{
+			Issuer:          "go-echo-example",
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 30 * 24 * time.Hour,
+		},
+	}
+}
+
+// Load builds the config from the defaults, the YAML file named by CONFIG_FILE (if any)
+// and the environment variables, in this order of precedence from lowest to highest.
+// Every environment variable can also be read from a file named by the variable with a _FILE suffix,
+// e.g. DB_PASSWORD_FILE=/run/secrets/db_password.
+func Load() (*Config, error) {
+	return LoadFile(os.Getenv("CONFIG_FILE"))
+}
+
+// LoadFile is Load with the YAML file given by path instead of CONFIG_FILE (none if empty).
+func LoadFile(path string) (*Config, error) {
+	conf := defaultConfig()
+
+	if path != "" {
+		if err := conf.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := conf.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := conf.validate(); err != nil {
+		return nil, err
+	}
+
+	return &conf, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	vars := []struct {
+		key string
+		set func(value string) error
+	}{
+		{"SERVER_ADDR", setString(&c.Server.Addr)},
+		{"SERVER_SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout)},
+		{"SERVER_REQUEST_TIMEOUT", setDuration(&c.Server.RequestTimeout)},
+		{"SERVER_TRUSTED_PROXIES", setStrings(&c.Server.TrustedProxies)},
+		{"DB_HOST", setString(&c.DB.Host)},
+		{"DB_PORT", setString(&c.DB.Port)},
+		{"DB_NAME", setString(&c.DB.Name)},
+		{"DB_USER", setString(&c.DB.User)},
+		{"DB_PASSWORD", setString(&c.DB.Password)},
+		{"DB_MAX_OPEN_CONNS", setInt(&c.DB.MaxOpenConns)},
+		{"DB_MAX_IDLE_CONNS", setInt(&c.DB.MaxIdleConns)},
+		{"DB_CONN_MAX_LIFETIME", setDuration(&c.DB.ConnMaxLifetime)},
+		{"DB_CONNECT_TIMEOUT", setDuration(&c.DB.ConnectTimeout)},
+		{"SESSION_KEY", setString(&c.Session.Key)},
+		{"USER_RETENTION", setDuration(&c.User.Retention)},
+		{"USER_PURGE_INTERVAL", setDuration(&c.User.PurgeInterval)},
+		{"MAIL_DRIVER", setString(&c.Mail.Driver)},
+		{"MAIL_DIR", setString(&c.Mail.Dir)},
+		{"MAIL_FROM", setString(&c.Mail.From)},
+		{"LOGIN_STORE", setString(&c.Login.Store)},
+		{"LOGIN_MAX_FAILURES_PER_USER", setInt(&c.Login.MaxFailuresPerUser)},
+		{"LOGIN_MAX_FAILURES_PER_IP", setInt(&c.Login.MaxFailuresPerIP)},
+		{"LOGIN_FAILURE_WINDOW", setDuration(&c.Login.FailureWindow)},
+		{"LOGIN_LOCKOUT_DURATION", setDuration(&c.Login.LockoutDuration)},
+		{"LOGIN_BASE_DELAY", setDuration(&c.Login.BaseDelay)},
+		{"LOGIN_MAX_DELAY", setDuration(&c.Login.MaxDelay)},
+		{"RATE_LIMIT_STORE", setString(&c.RateLimit.Store)},
+		{"RATE_LIMIT_FAIL_OPEN", setBool(&c.RateLimit.FailOpen)},
+		{"RATE_LIMIT_API_KEYS", setStrings(&c.RateLimit.APIKeys)},
+		{"RATE_LIMIT_SIGNUP_LIMIT", setInt(&c.RateLimit.Signup.Limit)},
+		{"RATE_LIMIT_SIGNUP_WINDOW", setDuration(&c.RateLimit.Signup.Window)},
+		{"RATE_LIMIT_SIGNUP_KEY", setString(&c.RateLimit.Signup.Key)},
+		{"RATE_LIMIT_AUTH_LIMIT", setInt(&c.RateLimit.Auth.Limit)},
+		{"RATE_LIMIT_AUTH_WINDOW", setDuration(&c.RateLimit.Auth.Window)},
+		{"RATE_LIMIT_AUTH_KEY", setString(&c.RateLimit.Auth.Key)},
+		{"RATE_LIMIT_API_LIMIT", setInt(&c.RateLimit.API.Limit)},
+		{"RATE_LIMIT_API_WINDOW", setDuration(&c.RateLimit.API.Window)},
+		{"RATE_LIMIT_API_KEY", setString(&c.RateLimit.API.Key)},
+		{"EMAIL_VERIFICATION_URL", setString(&c.EmailVerification.URL)},
+		{"EMAIL_VERIFICATION_TOKEN_TTL", setDuration(&c.EmailVerification.TokenTTL)},
+		{"EMAIL_VERIFICATION_RESEND_INTERVAL", setDuration(&c.EmailVerification.ResendInterval)},
+		{"EMAIL_VERIFICATION_REQUIRED", setBool(&c.EmailVerification.Required)},
+		{"PASSWORD_RESET_URL", setString(&c.PasswordReset.URL)},
+		{"PASSWORD_RESET_TOKEN_TTL", setDuration(&c.PasswordReset.TokenTTL)},
+		{"PASSWORD_RESET_RESEND_INTERVAL", setDuration(&c.PasswordReset.ResendInterval)},
+		{"TOKEN_ISSUER", setString(&c.Token.Issuer)},
+		{"TOKEN_ACCESS_TOKEN_TTL", setDuration(&c.Token.AccessTokenTTL)},
+		{"TOKEN_REFRESH_TOKEN_TTL", setDuration(&c.Token.RefreshTokenTTL)},
+		{"TOKEN_SIGNING_KEY", setString(&c.Token.SigningKey)},
+		{"TOKEN_SECRET", addTokenSecret(&c.Token.Keys)},
+	}
+
+	for _, v := range vars {
+		value, ok, err := lookupEnv(v.key)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		if err := v.set(value); err != nil {
+			return fmt.Errorf("invalid %s: %w", v.key, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Config) validate() error {
+	var errs []error
+
+	if c.Server.Addr == "" {
+		errs = append(errs, errors.New("server address is required"))
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		errs = append(errs, errors.New("server shutdown timeout must be positive"))
+	}
+	if c.Server.RequestTimeout < 0 {
+		errs = append(errs, errors.New("server request timeout must not be negative"))
+	}
+	for _, proxy := range c.Server.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil {
+			errs = append(errs, fmt.Errorf("invalid server trusted proxy: %w", err))
+		}
+	}
+	if c.DB.Host == "" {
+		errs = append(errs, errors.New("db host is required"))
+	}
+	if c.DB.Port == "" {
+		errs = append(errs, errors.New("db port is required"))
+	}
+	if c.DB.Name == "" {
+		errs = append(errs, errors.New("db name is required"))
+	}
+	if c.DB.User == "" {
+		errs = append(errs, errors.New("db user is required"))
+	}
+	if c.DB.Password == "" {
+		errs = append(errs, errors.New("db password is required"))
+	}
+	if c.DB.MaxOpenConns < 0 || c.DB.MaxIdleConns < 0 || c.DB.ConnMaxLifetime < 0 || c.DB.ConnectTimeout < 0 {
+		errs = append(errs, errors.New("db pool settings must not be negative"))
+	}
+	if len(c.Session.Key) < minSessionKeyLength {
+		errs = append(errs, fmt.Errorf("session key must be at least %d bytes", minSessionKeyLength))
+	}
+
+	if c.User.Retention < 0 {
+		errs = append(errs, errors.New("user retention must not be negative"))
+	}
+	if c.User.PurgeInterval <= 0 {
+		errs = append(errs, errors.New("user purge interval must be positive"))
+	}
+	switch c.Mail.Driver {
+	case "log":
+	case "file":
+		if c.Mail.Dir == "" {
+			errs = append(errs, errors.New("mail dir is required for the file driver"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("unknown mail driver %q", c.Mail.Driver))
+	}
+	if c.Mail.From == "" {
+		errs = append(errs, errors.New("mail from is required"))
+	}
+	errs = append(errs, c.Login.validate()...)
+	errs = append(errs, c.RateLimit.validate()...)
+	if c.EmailVerification.URL == "" {
+		errs = append(errs, errors.New("email verification url is required"))
+	}
+	if c.EmailVerification.TokenTTL <= 0 {
+		errs = append(errs, errors.New("email verification token ttl must be positive"))
+	}
+	if c.EmailVerification.ResendInterval < 0 {
+		errs = append(errs, errors.New("email verification resend interval must not be negative"))
+	}
+	if c.PasswordReset.URL == "" {
+		errs = append(errs, errors.New("password reset url is required"))
+	}
+	if c.PasswordReset.TokenTTL <= 0 {
+		errs = append(errs, errors.New("password reset token ttl must be positive"))
+	}
+	if c.PasswordReset.ResendInterval < 0 {
+		errs = append(errs, errors.New("password reset resend interval must not be negative"))
+	}
+
+	errs = append(errs, c.Token.validate()...)
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid config: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func (c *TokenConfig) validate() []error {
+	var errs []error
+
+	if c.Issuer == "" {
+		errs = append(errs, errors.New("token issuer is required"))
+	}
+	if c.AccessTokenTTL <= 0 || c.RefreshTokenTTL <= 0 {
+		errs = append(errs, errors.New("token ttls must be positive"))
+	}
+
+	ids := map[string]bool{}
+	for _, key := range c.Keys {
+		if key.ID == "" {
+			errs = append(errs, errors.New("token key id is required"))
+			continue
+		}
+		if ids[key.ID] {
+			errs = append(errs, fmt.Errorf("duplicate token key %s", key.ID))
+		}
+		ids[key.ID] = true
+
+		switch key.Algorithm {
+		case "HS256":
+			if len(key.Secret) < accesstoken.MinHMACSecretLength {
+				errs = append(errs, fmt.Errorf("token key %s: secret must be at least %d bytes", key.ID, accesstoken.MinHMACSecretLength))
+			}
+		case "RS256", "EdDSA":
+			if key.KeyFile == "" {
+				errs = append(errs, fmt.Errorf("token key %s: key file is required", key.ID))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("token key %s: unknown algorithm %q", key.ID, key.Algorithm))
+		}
+	}
+	if c.SigningKey != "" && !ids[c.SigningKey] {
+		errs = append(errs, fmt.Errorf("unknown token signing key %s", c.SigningKey))
+	}
+
+	return errs
+}
+
+func (c *LoginConfig) validate() []error {
+	var errs []error
+
+	switch c.Store {
+	case "postgres", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("unknown login store %q", c.Store))
+	}
+	if c.MaxFailuresPerUser < 0 || c.MaxFailuresPerIP < 0 {
+		errs = append(errs, errors.New("login max failures must not be negative"))
+	}
+	if c.FailureWindow <= 0 {
+		errs = append(errs, errors.New("login failure window must be positive"))
+	}
+	if c.LockoutDuration <= 0 {
+		errs = append(errs, errors.New("login lockout duration must be positive"))
+	}
+	if c.BaseDelay < 0 || c.MaxDelay < c.BaseDelay {
+		errs = append(errs, errors.New("login delays must not be negative, and max delay must not be less than base delay"))
+	}
+
+	return errs
+}
+
+func (c *RateLimitConfig) validate() []error {
+	var errs []error
+
+	switch c.Store {
+	case "postgres", "memory":
+	default:
+		errs = append(errs, fmt.Errorf("unknown rate limit store %q", c.Store))
+	}
+
+	policies := []struct {
+		name   string
+		policy RateLimitPolicyConfig
+	}{
+		{"signup", c.Signup},
+		{"auth", c.Auth},
+		{"api", c.API},
+	}
+	for _, p := range policies {
+		if p.policy.Limit < 0 {
+			errs = append(errs, fmt.Errorf("rate limit %s: limit must not be negative", p.name))
+		}
+		if p.policy.Limit == 0 {
+			continue
+		}
+		if p.policy.Window <= 0 {
+			errs = append(errs, fmt.Errorf("rate limit %s: window must be positive", p.name))
+		}
+		switch p.policy.Key {
+		case "ip", "user":
+		case "api_key":
+			if len(c.APIKeys) == 0 {
+				errs = append(errs, fmt.Errorf("rate limit %s: key api_key requires api keys", p.name))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("rate limit %s: unknown key %q", p.name, p.policy.Key))
+		}
+	}
+
+	return errs
+}
+
+// lookupEnv returns the value of the environment variable key,
+// or the content of the file named by key_FILE.
+func lookupEnv(key string) (string, bool, error) {
+	if value, ok := os.LookupEnv(key); ok {
+		return value, true, nil
+	}
+
+	path, ok := os.LookupEnv(key + "_FILE")
+	if !ok {
+		return "", false, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", false, fmt.Errorf("read %s_FILE: %w", key, err)
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func setString(dst *string) func(string) error {
+	return func(value string) error {
+		*dst = value
+		return nil
+	}
+}
+
+// setStrings splits a comma-separated list, e.g. SERVER_TRUSTED_PROXIES=10.0.0.0/8,192.168.0.0/16.
+func setStrings(dst *[]string) func(string) error {
+	return func(value string) error {
+		*dst = nil
+		for _, s := range strings.Split(value, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				*dst = append(*dst, s)
+			}
+		}
+		return nil
+	}
+}
+
+func setInt(dst *int) func(string) error {
+	return func(value string) error {
+		i, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		*dst = i
+		return nil
+	}
+}
+
+func setBool(dst *bool) func(string) error {
+	return func(value string) error {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		*dst = b
+		return nil
+	}
+}
+
+// addTokenSecret adds an HS256 key with the ID "env", which is the signing key unless another one is configured.
+func addTokenSecret(dst *[]TokenKeyConfig) func(string) error {
+	return func(value string) error {
+		*dst = append([]TokenKeyConfig{{ID: "env", Algorithm: "HS256", Secret: value}}, *dst...)
+		return nil
+	}
+}
+
+func setDuration(dst *time.Duration) func(string) error {
+	return func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*dst = d
+		return nil
+	}
+}
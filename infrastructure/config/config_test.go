@@ -0,0 +1,239 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/config"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSessionKey = "0123456789abcdef0123456789abcdef"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults and env", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("DB_MAX_OPEN_CONNS", "5")
+
+		conf, err := config.Load()
+		if assert.NoError(t, err) {
+			assert.Equal(t, ":1323", conf.Server.Addr)
+			assert.Equal(t, "localhost", conf.DB.Host)
+			assert.Equal(t, "password", conf.DB.Password)
+			assert.Equal(t, 5, conf.DB.MaxOpenConns)
+			assert.Equal(t, testSessionKey, conf.Session.Key)
+		}
+	})
+
+	t.Run("file is overridden by env", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+server:
+  addr: ":8080"
+db:
+  host: db
+  password: file-password
+  conn_max_lifetime: 1m
+session:
+  key: `+testSessionKey+`
+`)
+		t.Setenv("CONFIG_FILE", path)
+		t.Setenv("DB_HOST", "env-db")
+
+		conf, err := config.Load()
+		if assert.NoError(t, err) {
+			assert.Equal(t, ":8080", conf.Server.Addr)
+			assert.Equal(t, "env-db", conf.DB.Host)
+			assert.Equal(t, "file-password", conf.DB.Password)
+			assert.Equal(t, time.Minute, conf.DB.ConnMaxLifetime)
+		}
+	})
+
+	t.Run("file given by path", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+server:
+  addr: ":8080"
+`)
+		t.Setenv("CONFIG_FILE", writeFile(t, "other.yaml", `
+server:
+  addr: ":9090"
+`))
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+
+		conf, err := config.LoadFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, ":8080", conf.Server.Addr)
+		}
+	})
+
+	t.Run("secrets from files", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db_password", "secret-password\n"))
+		t.Setenv("SESSION_KEY_FILE", writeFile(t, "session_key", testSessionKey+"\n"))
+
+		conf, err := config.Load()
+		if assert.NoError(t, err) {
+			assert.Equal(t, "secret-password", conf.DB.Password)
+			assert.Equal(t, testSessionKey, conf.Session.Key)
+		}
+	})
+
+	t.Run("missing required fields", func(t *testing.T) {
+		_, err := config.Load()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "db password is required")
+			assert.Contains(t, err.Error(), "session key must be at least 32 bytes")
+		}
+	})
+
+	t.Run("invalid value", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("DB_CONN_MAX_LIFETIME", "forever")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, "invalid DB_CONN_MAX_LIFETIME")
+	})
+
+	t.Run("unknown mail driver", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("MAIL_DRIVER", "smtp")
+
+		_, err := config.Load()
+		assert.ErrorContains(t, err, `unknown mail driver "smtp"`)
+	})
+
+	t.Run("token keys", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+token:
+  signing_key: rs
+  keys:
+    - id: rs
+      algorithm: RS256
+      key_file: /run/secrets/token_rs.pem
+`)
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("TOKEN_SECRET", testSessionKey)
+
+		conf, err := config.LoadFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "rs", conf.Token.SigningKey)
+			assert.Equal(t, []config.TokenKeyConfig{
+				{ID: "env", Algorithm: "HS256", Secret: testSessionKey},
+				{ID: "rs", Algorithm: "RS256", KeyFile: "/run/secrets/token_rs.pem"},
+			}, conf.Token.Keys)
+		}
+	})
+
+	t.Run("invalid token keys", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+token:
+  signing_key: unknown
+  keys:
+    - id: hs
+      algorithm: HS256
+      secret: short
+    - id: ed
+      algorithm: EdDSA
+    - id: es
+      algorithm: ES256
+`)
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+
+		_, err := config.LoadFile(path)
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "token key hs: secret must be at least 32 bytes")
+			assert.Contains(t, err.Error(), "token key ed: key file is required")
+			assert.Contains(t, err.Error(), `token key es: unknown algorithm "ES256"`)
+			assert.Contains(t, err.Error(), "unknown token signing key unknown")
+		}
+	})
+
+	t.Run("login throttling and trusted proxies", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.0/8, 192.168.1.1/32")
+		t.Setenv("LOGIN_STORE", "memory")
+		t.Setenv("LOGIN_MAX_FAILURES_PER_USER", "3")
+
+		conf, err := config.Load()
+		if assert.NoError(t, err) {
+			assert.Equal(t, []string{"10.0.0.0/8", "192.168.1.1/32"}, conf.Server.TrustedProxies)
+			assert.Equal(t, "memory", conf.Login.Store)
+			assert.Equal(t, 3, conf.Login.MaxFailuresPerUser)
+			assert.Equal(t, 15*time.Minute, conf.Login.LockoutDuration)
+		}
+	})
+
+	t.Run("invalid login throttling and trusted proxies", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("SERVER_TRUSTED_PROXIES", "10.0.0.1")
+		t.Setenv("LOGIN_STORE", "redis")
+		t.Setenv("LOGIN_MAX_DELAY", "100ms")
+
+		_, err := config.Load()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), "invalid server trusted proxy")
+			assert.Contains(t, err.Error(), `unknown login store "redis"`)
+			assert.Contains(t, err.Error(), "max delay must not be less than base delay")
+		}
+	})
+
+	t.Run("rate limits", func(t *testing.T) {
+		path := writeFile(t, "config.yaml", `
+rate_limit:
+  store: memory
+  api_keys: [key01]
+  api:
+    limit: 100
+    window: 1m
+    key: api_key
+`)
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("RATE_LIMIT_SIGNUP_LIMIT", "0")
+		t.Setenv("RATE_LIMIT_FAIL_OPEN", "false")
+
+		conf, err := config.LoadFile(path)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "memory", conf.RateLimit.Store)
+			assert.False(t, conf.RateLimit.FailOpen)
+			assert.Equal(t, []string{"key01"}, conf.RateLimit.APIKeys)
+			assert.Equal(t, 0, conf.RateLimit.Signup.Limit)
+			assert.Equal(t, config.RateLimitPolicyConfig{Limit: 30, Window: time.Minute, Key: "ip"}, conf.RateLimit.Auth)
+			assert.Equal(t, config.RateLimitPolicyConfig{Limit: 100, Window: time.Minute, Key: "api_key"}, conf.RateLimit.API)
+		}
+	})
+
+	t.Run("invalid rate limits", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD", "password")
+		t.Setenv("SESSION_KEY", testSessionKey)
+		t.Setenv("RATE_LIMIT_STORE", "redis")
+		t.Setenv("RATE_LIMIT_SIGNUP_WINDOW", "0s")
+		t.Setenv("RATE_LIMIT_API_KEY", "session")
+		t.Setenv("RATE_LIMIT_AUTH_KEY", "api_key")
+
+		_, err := config.Load()
+		if assert.Error(t, err) {
+			assert.Contains(t, err.Error(), `unknown rate limit store "redis"`)
+			assert.Contains(t, err.Error(), "rate limit signup: window must be positive")
+			assert.Contains(t, err.Error(), `rate limit api: unknown key "session"`)
+			assert.Contains(t, err.Error(), "rate limit auth: key api_key requires api keys")
+		}
+	})
+}
@@ -1,9 +1,13 @@
 package infrastructure
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
+	"time"
 
 	"github.com/uptrace/bun"
 	"github.com/uptrace/bun/dialect/pgdialect"
@@ -11,21 +15,38 @@ import (
 	"github.com/uptrace/bun/extra/bundebug"
 )
 
+const (
+	initialConnectBackoff = 500 * time.Millisecond
+	maxConnectBackoff     = 5 * time.Second
+)
+
 type DBConfig struct {
 	Host     string
 	Port     string
 	DBName   string
 	User     string
 	Password string
+
+	// connection pool settings (zero means no limit)
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+
+	// ConnectTimeout is how long to keep retrying the first connection.
+	// Zero means a single attempt.
+	ConnectTimeout time.Duration
 }
 
-func NewDB(conf DBConfig) *bun.DB {
-	dsn := genDSN(conf)
-	sqlDB := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(dsn)))
+func NewDB(ctx context.Context, conf DBConfig) (*bun.DB, error) {
+	sqlDB := sql.OpenDB(pgdriver.NewConnector(pgdriver.WithDSN(conf.DSN())))
+	sqlDB.SetMaxOpenConns(conf.MaxOpenConns)
+	sqlDB.SetMaxIdleConns(conf.MaxIdleConns)
+	sqlDB.SetConnMaxLifetime(conf.ConnMaxLifetime)
 	db := bun.NewDB(sqlDB, pgdialect.New())
 
-	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed open")
+	if err := pingWithRetry(ctx, db, conf.ConnectTimeout); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("connect to database: %w", err)
 	}
 
 	db.AddQueryHook(bundebug.NewQueryHook(
@@ -33,16 +54,44 @@ func NewDB(conf DBConfig) *bun.DB {
 		bundebug.FromEnv("BUNDEBUG"),
 	))
 
-	return db
+	return db, nil
 }
 
-func genDSN(conf DBConfig) string {
-	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=disable",
-		conf.User,
-		conf.Password,
-		conf.Host,
-		conf.Port,
-		conf.DBName,
-	)
+// pingWithRetry pings the database with exponential backoff until it succeeds or timeout elapses.
+func pingWithRetry(ctx context.Context, db *bun.DB, timeout time.Duration) error {
+	if timeout <= 0 {
+		return db.PingContext(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	backoff := initialConnectBackoff
+	for {
+		err := db.PingContext(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Failed to connect to database, retrying in %s: %v", backoff, err)
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+
+		backoff = min(backoff*2, maxConnectBackoff)
+	}
+}
+
+// DSN returns the connection URL, escaping the credentials and the database name.
+func (conf DBConfig) DSN() string {
+	u := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(conf.User, conf.Password),
+		Host:     net.JoinHostPort(conf.Host, conf.Port),
+		Path:     "/" + conf.DBName,
+		RawQuery: "sslmode=disable",
+	}
+	return u.String()
 }
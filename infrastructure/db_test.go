@@ -0,0 +1,65 @@
+package infrastructure_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun/driver/pgdriver"
+)
+
+func TestDBConfigDSN(t *testing.T) {
+	cases := []struct {
+		name     string
+		user     string
+		password string
+	}{
+		{name: "plain", user: "root", password: "password"},
+		{name: "special characters", user: "app@example", password: "p@ss/w:rd%20#?"},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			conf := infrastructure.DBConfig{Host: "db", Port: "5432", DBName: "echo_example", User: tt.user, Password: tt.password}
+
+			// the driver parses the DSN back to the same settings
+			got := pgdriver.NewConnector(pgdriver.WithDSN(conf.DSN())).Config()
+			assert.Equal(t, tt.user, got.User)
+			assert.Equal(t, tt.password, got.Password)
+			assert.Equal(t, "db:5432", got.Addr)
+			assert.Equal(t, "echo_example", got.Database)
+		})
+	}
+}
+
+func TestNewDB(t *testing.T) {
+	// nothing listens on port 1, so every attempt is refused
+	conf := infrastructure.DBConfig{
+		Host:           "127.0.0.1",
+		Port:           "1",
+		DBName:         "echo_example",
+		User:           "root",
+		Password:       "password",
+		ConnectTimeout: 700 * time.Millisecond,
+	}
+
+	t.Run("returns error after retrying", func(t *testing.T) {
+		start := time.Now()
+		db, err := infrastructure.NewDB(context.Background(), conf)
+		assert.Error(t, err)
+		assert.Nil(t, db)
+		assert.GreaterOrEqual(t, time.Since(start), 500*time.Millisecond)
+	})
+
+	t.Run("stops retrying when context is canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		_, err := infrastructure.NewDB(ctx, conf)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), 500*time.Millisecond)
+	})
+}
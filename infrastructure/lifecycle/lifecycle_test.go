@@ -0,0 +1,94 @@
+package lifecycle_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/lifecycle"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShutdown(t *testing.T) {
+	t.Run("hooks run in reverse order", func(t *testing.T) {
+		lc := lifecycle.New()
+
+		var got []string
+		for _, name := range []string{"db", "worker", "server"} {
+			lc.OnShutdown(name, func(context.Context) error {
+				got = append(got, name)
+				return nil
+			})
+		}
+
+		assert.NoError(t, lc.Shutdown(context.Background()))
+		assert.Equal(t, []string{"server", "worker", "db"}, got)
+	})
+
+	t.Run("errors are joined and remaining hooks still run", func(t *testing.T) {
+		lc := lifecycle.New()
+
+		dbClosed := false
+		lc.OnShutdown("db", func(context.Context) error {
+			dbClosed = true
+			return nil
+		})
+		lc.OnShutdown("server", func(context.Context) error {
+			return errors.New("failed")
+		})
+
+		err := lc.Shutdown(context.Background())
+		assert.EqualError(t, err, "shutdown server: failed")
+		assert.True(t, dbClosed)
+	})
+
+	t.Run("background goroutine is canceled and awaited", func(t *testing.T) {
+		lc := lifecycle.New()
+
+		stopped := false
+		lc.Go("worker", func(ctx context.Context) {
+			<-ctx.Done()
+			stopped = true
+		})
+
+		assert.NoError(t, lc.Shutdown(context.Background()))
+		assert.True(t, stopped)
+	})
+
+	t.Run("periodic job runs until shutdown", func(t *testing.T) {
+		lc := lifecycle.New()
+
+		ran := make(chan struct{}, 1)
+		lc.Every("job", time.Millisecond, func(context.Context) error {
+			select {
+			case ran <- struct{}{}:
+			default:
+			}
+			return nil
+		})
+
+		select {
+		case <-ran:
+		case <-time.After(time.Second):
+			t.Fatal("job did not run")
+		}
+		assert.NoError(t, lc.Shutdown(context.Background()))
+	})
+
+	t.Run("timeout while waiting for background goroutine", func(t *testing.T) {
+		lc := lifecycle.New()
+
+		release := make(chan struct{})
+		defer close(release)
+		lc.Go("worker", func(context.Context) {
+			<-release
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		err := lc.Shutdown(ctx)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
@@ -0,0 +1,89 @@
+package loginattempt
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/usecase"
+)
+
+// MemoryStore keeps the login attempts in the process.
+// The counts are lost on restart and not shared between processes, so it suits a single instance.
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]usecase.LoginAttempts
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]usecase.LoginAttempts)}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (usecase.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.attempts[key], nil
+}
+
+func (s *MemoryStore) RecordFailure(_ context.Context, key string, now time.Time, window time.Duration) (usecase.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	attempts := s.attempts[key]
+	if attempts.LastFailureAt.Before(now.Add(-window)) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if attempts, ok := s.attempts[key]; ok && attempts.Failures > 0 {
+		attempts.Failures--
+		s.attempts[key] = attempts
+	}
+	return nil
+}
+
+func (s *MemoryStore) Lock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.attempts[key] = usecase.LoginAttempts{LockedUntil: until}
+	return nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.attempts, key)
+	return nil
+}
+
+// DeleteExpired forgets the keys that are not locked and have no failure within the window,
+// and returns the number of forgotten keys.
+func (s *MemoryStore) DeleteExpired(_ context.Context, window time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var n int64
+	for key, attempts := range s.attempts {
+		if isExpired(attempts, now, window) {
+			delete(s.attempts, key)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func isExpired(attempts usecase.LoginAttempts, now time.Time, window time.Duration) bool {
+	return !now.Before(attempts.LockedUntil) && attempts.LastFailureAt.Before(now.Add(-window))
+}
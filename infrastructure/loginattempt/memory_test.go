@@ -0,0 +1,82 @@
+package loginattempt_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/loginattempt"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("count failures within the window", func(t *testing.T) {
+		s := loginattempt.NewMemoryStore()
+
+		got, err := s.Get(ctx, "user:test01")
+		assert.NoError(t, err)
+		assert.Equal(t, usecase.LoginAttempts{}, got)
+
+		_, _ = s.RecordFailure(ctx, "user:test01", now.Add(-2*time.Hour), time.Hour)
+		got, err = s.RecordFailure(ctx, "user:test01", now.Add(-time.Minute), time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, got.Failures, "the failure before the window is not counted")
+
+		got, err = s.RecordFailure(ctx, "user:test01", now, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, usecase.LoginAttempts{Failures: 2, LastFailureAt: now}, got)
+
+		other, _ := s.Get(ctx, "ip:192.0.2.1")
+		assert.Equal(t, 0, other.Failures)
+	})
+
+	t.Run("lock and reset", func(t *testing.T) {
+		s := loginattempt.NewMemoryStore()
+		_, _ = s.RecordFailure(ctx, "user:test01", now, time.Hour)
+
+		assert.NoError(t, s.Lock(ctx, "user:test01", now.Add(time.Hour)))
+		got, _ := s.Get(ctx, "user:test01")
+		assert.Equal(t, usecase.LoginAttempts{LockedUntil: now.Add(time.Hour)}, got)
+
+		assert.NoError(t, s.Reset(ctx, "user:test01"))
+		got, _ = s.Get(ctx, "user:test01")
+		assert.Equal(t, usecase.LoginAttempts{}, got)
+	})
+
+	t.Run("release", func(t *testing.T) {
+		s := loginattempt.NewMemoryStore()
+		_, _ = s.RecordFailure(ctx, "ip:192.0.2.1", now, time.Hour)
+
+		assert.NoError(t, s.Release(ctx, "ip:192.0.2.1"))
+		got, _ := s.Get(ctx, "ip:192.0.2.1")
+		assert.Equal(t, usecase.LoginAttempts{LastFailureAt: now}, got)
+
+		// nothing is taken back below zero, nor from an unknown key
+		assert.NoError(t, s.Release(ctx, "ip:192.0.2.1"))
+		assert.NoError(t, s.Release(ctx, "ip:192.0.2.2"))
+		got, _ = s.Get(ctx, "ip:192.0.2.1")
+		assert.Equal(t, 0, got.Failures)
+		got, _ = s.Get(ctx, "ip:192.0.2.2")
+		assert.Equal(t, usecase.LoginAttempts{}, got)
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		s := loginattempt.NewMemoryStore()
+		_, _ = s.RecordFailure(ctx, "recent", now, time.Hour)
+		_, _ = s.RecordFailure(ctx, "old", now.Add(-2*time.Hour), time.Hour)
+		_ = s.Lock(ctx, "locked", now.Add(time.Hour))
+		_ = s.Lock(ctx, "unlocked", now.Add(-time.Minute))
+
+		n, err := s.DeleteExpired(ctx, time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		for key, want := range map[string]bool{"recent": true, "old": false, "locked": true, "unlocked": false} {
+			got, _ := s.Get(ctx, key)
+			assert.Equal(t, want, got != usecase.LoginAttempts{}, key)
+		}
+	})
+}
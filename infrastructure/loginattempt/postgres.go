@@ -0,0 +1,113 @@
+package loginattempt
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/uptrace/bun"
+)
+
+type LoginAttemptModel struct {
+	bun.BaseModel `bun:"table:login_attempts,alias:la"`
+
+	Key           string    `bun:"key,pk"`
+	Failures      int       `bun:"failures,notnull"`
+	LastFailureAt time.Time `bun:"last_failure_at,nullzero"`
+	LockedUntil   time.Time `bun:"locked_until,nullzero"`
+}
+
+func (m *LoginAttemptModel) toUseCase() usecase.LoginAttempts {
+	return usecase.LoginAttempts{
+		Failures:      m.Failures,
+		LastFailureAt: m.LastFailureAt,
+		LockedUntil:   m.LockedUntil,
+	}
+}
+
+// PostgresStore keeps the login attempts in the login_attempts table, so that all processes share them.
+type PostgresStore struct {
+	db *bun.DB
+}
+
+func NewPostgresStore(db *bun.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Get(ctx context.Context, key string) (usecase.LoginAttempts, error) {
+	var attemptModel LoginAttemptModel
+	if err := s.db.NewSelect().
+		Model(&attemptModel).
+		Where("key = ?", key).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return usecase.LoginAttempts{}, nil
+		}
+		return usecase.LoginAttempts{}, err
+	}
+	return attemptModel.toUseCase(), nil
+}
+
+func (s *PostgresStore) RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (usecase.LoginAttempts, error) {
+	// a single statement, so that concurrent failures are all counted.
+	// the existing row is referred to by the table alias, which bun adds to an upsert.
+	attemptModel := LoginAttemptModel{Key: key, Failures: 1, LastFailureAt: now}
+	if _, err := s.db.NewInsert().
+		Model(&attemptModel).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = CASE WHEN la.last_failure_at >= ? THEN la.failures + 1 ELSE 1 END", now.Add(-window)).
+		Set("last_failure_at = EXCLUDED.last_failure_at").
+		Returning("*").
+		Exec(ctx); err != nil {
+		return usecase.LoginAttempts{}, err
+	}
+	return attemptModel.toUseCase(), nil
+}
+
+func (s *PostgresStore) Release(ctx context.Context, key string) error {
+	// a lock in between has cleared the failures already
+	_, err := s.db.NewUpdate().
+		Model((*LoginAttemptModel)(nil)).
+		Set("failures = failures - 1").
+		Where("key = ?", key).
+		Where("failures > 0").
+		Exec(ctx)
+	return err
+}
+
+func (s *PostgresStore) Lock(ctx context.Context, key string, until time.Time) error {
+	attemptModel := LoginAttemptModel{Key: key, LockedUntil: until}
+	_, err := s.db.NewInsert().
+		Model(&attemptModel).
+		On("CONFLICT (key) DO UPDATE").
+		Set("failures = 0").
+		Set("last_failure_at = NULL").
+		Set("locked_until = EXCLUDED.locked_until").
+		Exec(ctx)
+	return err
+}
+
+func (s *PostgresStore) Reset(ctx context.Context, key string) error {
+	_, err := s.db.NewDelete().
+		Model((*LoginAttemptModel)(nil)).
+		Where("key = ?", key).
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpired deletes the keys that are not locked and have no failure within the window,
+// and returns the number of deleted keys.
+func (s *PostgresStore) DeleteExpired(ctx context.Context, window time.Duration) (int64, error) {
+	now := time.Now()
+	res, err := s.db.NewDelete().
+		Model((*LoginAttemptModel)(nil)).
+		Where("locked_until IS NULL OR locked_until <= ?", now).
+		Where("last_failure_at IS NULL OR last_failure_at < ?", now.Add(-window)).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,29 @@
+CREATE TABLE users (
+    id SERIAL NOT NULL,
+    name VARCHAR(32) NOT NULL,
+    password VARCHAR(255) NOT NULL,
+    email VARCHAR(64) NOT NULL,
+    birth_day DATE NOT NULL,
+    email_verified_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    -- the application looks up the emails in lower case
+    CONSTRAINT users_email_lower_check CHECK (email = lower(email))
+);
+
+--bun:split
+
+-- names and emails are unique regardless of case
+CREATE UNIQUE INDEX users_name_lower_key ON users (lower(name));
+
+--bun:split
+
+CREATE UNIQUE INDEX users_email_lower_key ON users (lower(email));
+
+--bun:split
+
+CREATE INDEX users_birth_day_id_idx ON users (birth_day, id);
+
+--bun:split
+
+CREATE INDEX users_deleted_at_idx ON users (deleted_at) WHERE deleted_at IS NOT NULL;
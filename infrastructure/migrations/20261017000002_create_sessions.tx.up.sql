@@ -0,0 +1,16 @@
+CREATE TABLE sessions (
+    id VARCHAR(64) NOT NULL,
+    user_id INTEGER REFERENCES users (id) ON DELETE CASCADE,
+    data BYTEA NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (id)
+);
+
+--bun:split
+
+CREATE INDEX sessions_expires_at_idx ON sessions (expires_at);
+
+--bun:split
+
+CREATE INDEX sessions_user_id_idx ON sessions (user_id);
@@ -0,0 +1,15 @@
+CREATE TABLE user_tokens (
+    id SERIAL NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    purpose VARCHAR(32) NOT NULL,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    used_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    UNIQUE (token_hash)
+);
+
+--bun:split
+
+CREATE INDEX user_tokens_user_id_purpose_idx ON user_tokens (user_id, purpose);
@@ -0,0 +1,23 @@
+-- the permissions of each role are defined in the code (domain.Role)
+CREATE TABLE roles (
+    name VARCHAR(32) NOT NULL,
+    PRIMARY KEY (name)
+);
+
+--bun:split
+
+INSERT INTO roles (name) VALUES ('admin'), ('member');
+
+--bun:split
+
+CREATE TABLE user_roles (
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role VARCHAR(32) NOT NULL REFERENCES roles (name),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, role)
+);
+
+--bun:split
+
+-- existing users are members
+INSERT INTO user_roles (user_id, role) SELECT id, 'member' FROM users;
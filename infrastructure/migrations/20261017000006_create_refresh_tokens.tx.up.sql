@@ -0,0 +1,18 @@
+CREATE TABLE refresh_tokens (
+    id SERIAL NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    PRIMARY KEY (id),
+    UNIQUE (token_hash)
+);
+
+--bun:split
+
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+--bun:split
+
+CREATE INDEX refresh_tokens_expires_at_idx ON refresh_tokens (expires_at);
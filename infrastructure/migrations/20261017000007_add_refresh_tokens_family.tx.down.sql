@@ -0,0 +1,13 @@
+CREATE INDEX refresh_tokens_user_id_idx ON refresh_tokens (user_id);
+
+--bun:split
+
+DROP INDEX refresh_tokens_user_id_family_id_idx;
+
+--bun:split
+
+ALTER TABLE refresh_tokens
+    DROP COLUMN family_id,
+    DROP COLUMN device,
+    DROP COLUMN auth_time,
+    DROP COLUMN used_at;
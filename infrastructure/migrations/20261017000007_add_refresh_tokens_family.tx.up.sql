@@ -0,0 +1,26 @@
+-- the tokens replacing each other form a family, the login of one device
+ALTER TABLE refresh_tokens
+    ADD COLUMN family_id VARCHAR(64),
+    ADD COLUMN device VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN auth_time TIMESTAMPTZ,
+    ADD COLUMN used_at TIMESTAMPTZ;
+
+--bun:split
+
+-- a token issued before is the only one of its family.
+-- the family ID is listed as the session ID, so it must not reveal the token hash
+UPDATE refresh_tokens SET family_id = gen_random_uuid()::text, auth_time = created_at;
+
+--bun:split
+
+ALTER TABLE refresh_tokens
+    ALTER COLUMN family_id SET NOT NULL,
+    ALTER COLUMN auth_time SET NOT NULL;
+
+--bun:split
+
+CREATE INDEX refresh_tokens_user_id_family_id_idx ON refresh_tokens (user_id, family_id);
+
+--bun:split
+
+DROP INDEX refresh_tokens_user_id_idx;
@@ -0,0 +1,7 @@
+CREATE TABLE login_attempts (
+    key VARCHAR(300) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMPTZ,
+    locked_until TIMESTAMPTZ,
+    PRIMARY KEY (key)
+);
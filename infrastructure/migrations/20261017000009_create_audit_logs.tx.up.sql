@@ -0,0 +1,13 @@
+CREATE TABLE audit_logs (
+    id SERIAL NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    subject VARCHAR(300) NOT NULL,
+    ip VARCHAR(45) NOT NULL DEFAULT '',
+    detail TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (id)
+);
+
+--bun:split
+
+CREATE INDEX audit_logs_created_at_idx ON audit_logs (created_at);
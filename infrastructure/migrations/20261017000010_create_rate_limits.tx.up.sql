@@ -0,0 +1,9 @@
+CREATE TABLE rate_limits (
+    key VARCHAR(300) NOT NULL,
+    tat TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (key)
+);
+
+--bun:split
+
+CREATE INDEX rate_limits_tat_idx ON rate_limits (tat);
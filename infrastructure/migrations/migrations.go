@@ -0,0 +1,80 @@
+// Package migrations holds the versioned database schema.
+//
+// SQL migrations are <version>_<name>.[tx.]up.sql and .down.sql files in this directory,
+// Go migrations are <version>_<name>.go files registering themselves with Migrations.MustRegister.
+package migrations
+
+import (
+	"context"
+	"embed"
+	"errors"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+const (
+	tableName      = "bun_migrations"
+	locksTableName = "bun_migration_locks"
+)
+
+//go:embed *.sql
+var sqlMigrations embed.FS
+
+// Migrations is the set of all migrations, ordered by version.
+var Migrations = migrate.NewMigrations()
+
+func init() {
+	if err := Migrations.Discover(sqlMigrations); err != nil {
+		panic(err)
+	}
+}
+
+func NewMigrator(db *bun.DB) *migrate.Migrator {
+	return migrate.NewMigrator(db, Migrations,
+		migrate.WithTableName(tableName),
+		migrate.WithLocksTableName(locksTableName),
+		migrate.WithMarkAppliedOnSuccess(true),
+	)
+}
+
+// Up applies all pending migrations as one group.
+// The group is empty if the schema is up to date.
+func Up(ctx context.Context, m *migrate.Migrator) (*migrate.MigrationGroup, error) {
+	return withLock(ctx, m, m.Migrate)
+}
+
+// Down rolls back the last applied group.
+// The group is empty if no migration has been applied.
+func Down(ctx context.Context, m *migrate.Migrator) (*migrate.MigrationGroup, error) {
+	return withLock(ctx, m, m.Rollback)
+}
+
+// Status returns all migrations with the time they have been applied at, zero if pending.
+func Status(ctx context.Context, m *migrate.Migrator) (migrate.MigrationSlice, error) {
+	if err := m.Init(ctx); err != nil {
+		return nil, err
+	}
+	return m.MigrationsWithStatus(ctx)
+}
+
+// withLock runs fn holding the row in the locks table,
+// so that concurrent deployments cannot apply the same migration twice.
+func withLock(
+	ctx context.Context,
+	m *migrate.Migrator,
+	fn func(context.Context, ...migrate.MigrationOption) (*migrate.MigrationGroup, error),
+) (group *migrate.MigrationGroup, err error) {
+	if err := m.Init(ctx); err != nil {
+		return nil, err
+	}
+	if err := m.Lock(ctx); err != nil {
+		return nil, err
+	}
+	defer func() {
+		// release the lock even if the context has been canceled
+		err = errors.Join(err, m.Unlock(context.WithoutCancel(ctx)))
+	}()
+
+	return fn(ctx)
+}
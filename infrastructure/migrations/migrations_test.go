@@ -0,0 +1,33 @@
+package migrations_test
+
+import (
+	"testing"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/migrations"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	sorted := migrations.Migrations.Sorted()
+
+	names := make([]string, 0, len(sorted))
+	for _, m := range sorted {
+		names = append(names, m.String())
+
+		// every migration has to be reversible
+		assert.NotNil(t, m.Up, m.String())
+		assert.NotNil(t, m.Down, m.String())
+	}
+	assert.Equal(t, []string{
+		"20261017000001_create_users",
+		"20261017000002_create_sessions",
+		"20261017000003_create_user_tokens",
+		"20261017000004_add_users_disabled_at",
+		"20261017000005_create_roles",
+		"20261017000006_create_refresh_tokens",
+		"20261017000007_add_refresh_tokens_family",
+		"20261017000008_create_login_attempts",
+		"20261017000009_create_audit_logs",
+		"20261017000010_create_rate_limits",
+	}, names)
+}
@@ -0,0 +1,64 @@
+package password
+
+import (
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHashFormat = errors.New("unknown password hash format")
+
+// Algorithm is a single password hashing scheme.
+// Encoded hashes carry an algorithm prefix so that Identify can recognize them.
+type Algorithm interface {
+	Hash(password string) (string, error)
+	Verify(password, encodedHash string) (matched bool, needsRehash bool, err error)
+	Identify(encodedHash string) bool
+}
+
+// Hasher hashes new passwords with the preferred algorithm and verifies
+// hashes produced by any of the registered algorithms.
+type Hasher struct {
+	preferred  Algorithm
+	algorithms []Algorithm
+}
+
+func NewHasher(preferred Algorithm, others ...Algorithm) *Hasher {
+	return &Hasher{
+		preferred:  preferred,
+		algorithms: append([]Algorithm{preferred}, others...),
+	}
+}
+
+// NewDefaultHasher hashes with argon2id and still accepts bcrypt hashes, which are upgraded on login.
+func NewDefaultHasher() *Hasher {
+	return NewHasher(
+		NewArgon2idHasher(DefaultArgon2idParams),
+		NewBcryptHasher(bcrypt.DefaultCost),
+	)
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.preferred.Hash(password)
+}
+
+func (h *Hasher) Verify(password, encodedHash string) (bool, bool, error) {
+	for _, algorithm := range h.algorithms {
+		if !algorithm.Identify(encodedHash) {
+			continue
+		}
+
+		matched, needsRehash, err := algorithm.Verify(password, encodedHash)
+		if err != nil {
+			return false, false, err
+		}
+
+		// hashes of a non-preferred algorithm are always upgraded
+		if algorithm != h.preferred {
+			needsRehash = true
+		}
+		return matched, needsRehash, nil
+	}
+
+	return false, false, ErrUnknownHashFormat
+}
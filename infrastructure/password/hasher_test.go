@@ -0,0 +1,97 @@
+package password_test
+
+import (
+	"testing"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/password"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testArgon2idParams = password.Argon2idParams{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+func TestHasher(t *testing.T) {
+	argon2id := password.NewArgon2idHasher(testArgon2idParams)
+	bcryptHasher := password.NewBcryptHasher(bcrypt.MinCost)
+
+	t.Run("argon2id", func(t *testing.T) {
+		h := password.NewHasher(argon2id, bcryptHasher)
+
+		hash, err := h.Hash("test01")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Regexp(t, `^\$argon2id\$v=19\$m=1024,t=1,p=1\$`, hash)
+
+		matched, needsRehash, err := h.Verify("test01", hash)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+		assert.False(t, needsRehash)
+
+		matched, _, err = h.Verify("wrong", hash)
+		assert.NoError(t, err)
+		assert.False(t, matched)
+	})
+
+	t.Run("bcrypt", func(t *testing.T) {
+		h := password.NewHasher(bcryptHasher)
+
+		hash, err := h.Hash("test01")
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Regexp(t, `^\$2a\$04\$`, hash)
+
+		matched, needsRehash, err := h.Verify("test01", hash)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+		assert.False(t, needsRehash)
+
+		matched, _, err = h.Verify("wrong", hash)
+		assert.NoError(t, err)
+		assert.False(t, matched)
+	})
+
+	t.Run("needs rehash when parameters changed", func(t *testing.T) {
+		hash, err := argon2id.Hash("test01")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		params := testArgon2idParams
+		params.Iterations = 2
+		h := password.NewHasher(password.NewArgon2idHasher(params))
+
+		matched, needsRehash, err := h.Verify("test01", hash)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+		assert.True(t, needsRehash)
+	})
+
+	t.Run("needs rehash when algorithm is not preferred", func(t *testing.T) {
+		hash, err := bcryptHasher.Hash("test01")
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		h := password.NewHasher(argon2id, bcryptHasher)
+
+		matched, needsRehash, err := h.Verify("test01", hash)
+		assert.NoError(t, err)
+		assert.True(t, matched)
+		assert.True(t, needsRehash)
+	})
+
+	t.Run("unknown hash format", func(t *testing.T) {
+		h := password.NewHasher(argon2id, bcryptHasher)
+
+		_, _, err := h.Verify("test01", "test01")
+		assert.ErrorIs(t, err, password.ErrUnknownHashFormat)
+	})
+}
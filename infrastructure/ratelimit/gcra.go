@@ -0,0 +1,46 @@
+// Package ratelimit implements the rate limit stores of controller.RateLimitMiddleware.
+//
+// The limits are token buckets computed with the generic cell rate algorithm (GCRA):
+// a key only keeps its theoretical arrival time (TAT), the time at which its bucket is full again.
+// Each request moves the TAT by window/limit, and is rejected if that would put it more than a window ahead of now.
+package ratelimit
+
+import (
+	"time"
+
+	"github.com/ricky2122/go-echo-example/controller"
+)
+
+// take takes one request from the bucket of the TAT at now, and returns the result and the new TAT.
+// The TAT is unchanged when the request is not allowed.
+func take(tat, now time.Time, limit int, window time.Duration) (controller.RateLimitResult, time.Time) {
+	interval := window / time.Duration(limit)
+	if tat.Before(now) {
+		tat = now
+	}
+
+	newTAT := tat.Add(interval)
+	if newTAT.Add(-window).After(now) {
+		return rejected(tat, now, limit, window), tat
+	}
+	return allowed(newTAT, now, limit, window), newTAT
+}
+
+// allowed returns the result of a request that moved the TAT to newTAT.
+func allowed(newTAT, now time.Time, limit int, window time.Duration) controller.RateLimitResult {
+	interval := window / time.Duration(limit)
+	return controller.RateLimitResult{
+		Allowed:   true,
+		Remaining: int((window - newTAT.Sub(now)) / interval),
+		Reset:     newTAT.Sub(now),
+	}
+}
+
+// rejected returns the result of a request that the TAT did not allow.
+func rejected(tat, now time.Time, limit int, window time.Duration) controller.RateLimitResult {
+	interval := window / time.Duration(limit)
+	return controller.RateLimitResult{
+		Reset:      tat.Sub(now),
+		RetryAfter: tat.Add(interval - window).Sub(now),
+	}
+}
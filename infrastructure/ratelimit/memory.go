@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/controller"
+)
+
+// MemoryStore keeps the limits in the process, so each process allows the full limit.
+type MemoryStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{tats: make(map[string]time.Time)}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, limit int, window time.Duration) (controller.RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, tat := take(s.tats[key], time.Now(), limit, window)
+	s.tats[key] = tat
+	return result, nil
+}
+
+// DeleteExpired forgets the keys whose bucket is full again, and returns the number of forgotten keys.
+func (s *MemoryStore) DeleteExpired(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	var n int64
+	for key, tat := range s.tats {
+		if !tat.After(now) {
+			delete(s.tats, key)
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,67 @@
+package ratelimit_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/ratelimit"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("allow the limit at once, then one request per interval", func(t *testing.T) {
+		s := ratelimit.NewMemoryStore()
+
+		for i := 0; i < 3; i++ {
+			got, err := s.Take(ctx, "signup:ip:192.0.2.1", 3, time.Hour)
+			if assert.NoError(t, err) {
+				assert.True(t, got.Allowed, i)
+				assert.Equal(t, 2-i, got.Remaining, i)
+				assert.InDelta(t, time.Duration(i+1)*20*time.Minute, got.Reset, float64(time.Second), i)
+			}
+		}
+
+		got, err := s.Take(ctx, "signup:ip:192.0.2.1", 3, time.Hour)
+		if assert.NoError(t, err) {
+			assert.False(t, got.Allowed)
+			assert.Equal(t, 0, got.Remaining)
+			assert.InDelta(t, 20*time.Minute, got.RetryAfter, float64(time.Second))
+			assert.InDelta(t, time.Hour, got.Reset, float64(time.Second))
+		}
+
+		// other keys have their own limit
+		got, _ = s.Take(ctx, "signup:ip:192.0.2.2", 3, time.Hour)
+		assert.True(t, got.Allowed)
+	})
+
+	t.Run("refill over time", func(t *testing.T) {
+		s := ratelimit.NewMemoryStore()
+
+		got, _ := s.Take(ctx, "key", 1, 50*time.Millisecond)
+		assert.True(t, got.Allowed)
+		got, _ = s.Take(ctx, "key", 1, 50*time.Millisecond)
+		assert.False(t, got.Allowed)
+
+		time.Sleep(60 * time.Millisecond)
+		got, _ = s.Take(ctx, "key", 1, 50*time.Millisecond)
+		assert.True(t, got.Allowed)
+	})
+
+	t.Run("delete expired", func(t *testing.T) {
+		s := ratelimit.NewMemoryStore()
+		_, _ = s.Take(ctx, "short", 1, time.Millisecond)
+		_, _ = s.Take(ctx, "long", 1, time.Hour)
+		time.Sleep(5 * time.Millisecond)
+
+		n, err := s.DeleteExpired(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), n)
+
+		// the long key is still limited
+		got, _ := s.Take(ctx, "long", 1, time.Hour)
+		assert.False(t, got.Allowed)
+	})
+}
@@ -0,0 +1,73 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/uptrace/bun"
+)
+
+type RateLimitModel struct {
+	bun.BaseModel `bun:"table:rate_limits,alias:rl"`
+
+	Key string    `bun:"key,pk"`
+	TAT time.Time `bun:"tat,notnull"`
+}
+
+// PostgresStore keeps the limits in the rate_limits table, so that all processes share them.
+type PostgresStore struct {
+	db *bun.DB
+}
+
+func NewPostgresStore(db *bun.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// takeQuery is GCRA in a single statement, so that concurrent requests of a key are serialized by the row lock
+// of the upsert without a transaction. An allowed request moves the TAT and returns it as next_tat;
+// a rejected one leaves the row as it is, and its result is computed from prev_tat, read in the same statement.
+const takeQuery = `
+WITH prev AS (
+	SELECT tat FROM rate_limits WHERE key = ?0
+), next AS (
+	INSERT INTO rate_limits AS rl (key, tat) VALUES (?0, ?1::timestamptz + ?2 * interval '1 microsecond')
+	ON CONFLICT (key) DO UPDATE SET tat = GREATEST(rl.tat, ?1::timestamptz) + ?2 * interval '1 microsecond'
+	WHERE GREATEST(rl.tat, ?1::timestamptz) + (?2 - ?3) * interval '1 microsecond' <= ?1::timestamptz
+	RETURNING tat
+)
+SELECT (SELECT tat FROM next) AS next_tat, (SELECT tat FROM prev) AS prev_tat`
+
+func (s *PostgresStore) Take(ctx context.Context, key string, limit int, window time.Duration) (controller.RateLimitResult, error) {
+	now := time.Now()
+	interval := window / time.Duration(limit)
+	var nextTAT, prevTAT sql.NullTime
+	if err := s.db.NewRaw(takeQuery, key, now, interval.Microseconds(), window.Microseconds()).
+		Scan(ctx, &nextTAT, &prevTAT); err != nil {
+		return controller.RateLimitResult{}, err
+	}
+
+	if nextTAT.Valid {
+		return allowed(nextTAT.Time, now, limit, window), nil
+	}
+	// prev_tat is read before the upsert waits for a concurrent request, so it may be behind the TAT
+	// that rejected the request, which is at least this
+	tat := now.Add(window - interval)
+	if prevTAT.Valid && prevTAT.Time.After(tat) {
+		tat = prevTAT.Time
+	}
+	return rejected(tat, now, limit, window), nil
+}
+
+// DeleteExpired deletes the keys whose bucket is full again, and returns the number of deleted keys.
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.NewDelete().
+		Model((*RateLimitModel)(nil)).
+		Where("tat <= ?", time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
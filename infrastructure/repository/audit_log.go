@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/uptrace/bun"
+)
+
+type AuditLogModel struct {
+	bun.BaseModel `bun:"table:audit_logs,alias:al"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	Action    string    `bun:"action,notnull"`
+	Subject   string    `bun:"subject,notnull"`
+	IP        string    `bun:"ip,notnull"`
+	Detail    string    `bun:"detail,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+type AuditLogRepository struct {
+	db *bun.DB
+}
+
+func NewAuditLogRepository(db *bun.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (alr *AuditLogRepository) Create(ctx context.Context, entry usecase.AuditEntry) error {
+	logModel := AuditLogModel{
+		Action:  entry.Action,
+		Subject: entry.Subject,
+		IP:      entry.IP,
+		Detail:  entry.Detail,
+	}
+	_, err := alr.db.NewInsert().Model(&logModel).Exec(ctx)
+	return err
+}
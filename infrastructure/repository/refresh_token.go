@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/uptrace/bun"
+)
+
+type RefreshTokenModel struct {
+	bun.BaseModel `bun:"table:refresh_tokens,alias:rt"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	UserID    int       `bun:"user_id,notnull"`
+	FamilyID  string    `bun:"family_id,notnull"`
+	TokenHash string    `bun:"token_hash,notnull,unique"`
+	Device    string    `bun:"device,notnull"`
+	AuthTime  time.Time `bun:"auth_time,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	UsedAt    time.Time `bun:"used_at,nullzero"`
+	RevokedAt time.Time `bun:"revoked_at,nullzero"`
+}
+
+func (m *RefreshTokenModel) toDomain() domain.RefreshToken {
+	return domain.ReconstructRefreshToken(
+		domain.UserID(m.UserID),
+		m.FamilyID,
+		m.TokenHash,
+		m.Device,
+		m.AuthTime,
+		m.CreatedAt,
+		m.ExpiresAt,
+		m.UsedAt,
+		m.RevokedAt,
+	)
+}
+
+type RefreshTokenRepository struct {
+	db *bun.DB
+}
+
+func NewRefreshTokenRepository(db *bun.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+func (rtr *RefreshTokenRepository) Create(ctx context.Context, token domain.RefreshToken) error {
+	tokenModel := RefreshTokenModel{
+		UserID:    token.GetUserID().Int(),
+		FamilyID:  token.GetFamilyID(),
+		TokenHash: token.GetTokenHash(),
+		Device:    token.GetDevice(),
+		AuthTime:  token.GetAuthTime(),
+		CreatedAt: token.GetCreatedAt(),
+		ExpiresAt: token.GetExpiresAt(),
+	}
+	_, err := rtr.db.NewInsert().Model(&tokenModel).Exec(ctx)
+	return err
+}
+
+func (rtr *RefreshTokenRepository) GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	var tokenModel RefreshTokenModel
+	if err := rtr.db.NewSelect().
+		Model(&tokenModel).
+		Where("token_hash = ?", tokenHash).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	token := tokenModel.toDomain()
+	return &token, nil
+}
+
+func (rtr *RefreshTokenRepository) MarkUsed(ctx context.Context, tokenHash string) (bool, error) {
+	// a single statement, so that only one of concurrent requests marks the token
+	res, err := rtr.db.NewUpdate().
+		Model((*RefreshTokenModel)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("token_hash = ?", tokenHash).
+		Where("used_at IS NULL").
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+func (rtr *RefreshTokenRepository) GetActiveByUserID(ctx context.Context, userID domain.UserID) ([]domain.RefreshToken, error) {
+	var tokenModels []RefreshTokenModel
+	if err := rtr.db.NewSelect().
+		Model(&tokenModels).
+		Where("user_id = ?", userID).
+		Where("used_at IS NULL").
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Scan(ctx); err != nil {
+		return nil, err
+	}
+
+	tokens := make([]domain.RefreshToken, 0, len(tokenModels))
+	for _, tokenModel := range tokenModels {
+		tokens = append(tokens, tokenModel.toDomain())
+	}
+	return tokens, nil
+}
+
+func (rtr *RefreshTokenRepository) RevokeFamily(ctx context.Context, userID domain.UserID, familyID string) (bool, error) {
+	active, err := rtr.db.NewSelect().
+		Model((*RefreshTokenModel)(nil)).
+		Where("user_id = ?", userID).
+		Where("family_id = ?", familyID).
+		Where("used_at IS NULL").
+		Where("revoked_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Exists(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	// the used tokens are revoked as well, and are kept so that presenting one is still detected as reuse
+	_, err = rtr.db.NewUpdate().
+		Model((*RefreshTokenModel)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("family_id = ?", familyID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+	return active, nil
+}
+
+// RevokeByUserID revokes all refresh tokens of the user.
+func (rtr *RefreshTokenRepository) RevokeByUserID(ctx context.Context, userID domain.UserID) error {
+	_, err := rtr.db.NewUpdate().
+		Model((*RefreshTokenModel)(nil)).
+		Set("revoked_at = ?", time.Now()).
+		Where("user_id = ?", userID).
+		Where("revoked_at IS NULL").
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpired deletes the expired tokens and returns the number of deleted tokens.
+// Used tokens are kept until then, so that presenting one again is detected as reuse.
+func (rtr *RefreshTokenRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := rtr.db.NewDelete().
+		Model((*RefreshTokenModel)(nil)).
+		Where("expires_at <= ?", time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/uptrace/bun"
+)
+
+type UserRoleModel struct {
+	bun.BaseModel `bun:"table:user_roles,alias:ur"`
+
+	UserID    int       `bun:"user_id,pk"`
+	Role      string    `bun:"role,pk"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+}
+
+type RoleRepository struct {
+	db *bun.DB
+}
+
+func NewRoleRepository(db *bun.DB) *RoleRepository {
+	return &RoleRepository{db: db}
+}
+
+// GetRolesByUserID returns the roles of the user ordered by name.
+// Roles that are not defined in the code grant nothing and are skipped.
+func (rr *RoleRepository) GetRolesByUserID(ctx context.Context, userID domain.UserID) ([]domain.Role, error) {
+	var names []string
+	err := rr.db.NewSelect().
+		Model((*UserRoleModel)(nil)).
+		Column("role").
+		Where("user_id = ?", userID.Int()).
+		Order("role").
+		Scan(ctx, &names)
+	if err != nil {
+		return nil, err
+	}
+
+	roles := make([]domain.Role, 0, len(names))
+	for _, name := range names {
+		role, err := domain.NewRole(name)
+		if err != nil {
+			if errors.Is(err, domain.ErrUnknownRole) {
+				continue
+			}
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (rr *RoleRepository) Assign(ctx context.Context, userID domain.UserID, role domain.RoleName) error {
+	userRoleModel := UserRoleModel{
+		UserID: userID.Int(),
+		Role:   string(role),
+	}
+	_, err := rr.db.NewInsert().
+		Model(&userRoleModel).
+		On("CONFLICT DO NOTHING").
+		Exec(ctx)
+	return err
+}
+
+func (rr *RoleRepository) Revoke(ctx context.Context, userID domain.UserID, role domain.RoleName) error {
+	_, err := rr.db.NewDelete().
+		Model((*UserRoleModel)(nil)).
+		Where("user_id = ?", userID.Int()).
+		Where("role = ?", string(role)).
+		Exec(ctx)
+	return err
+}
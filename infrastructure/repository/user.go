@@ -4,22 +4,36 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
 )
 
 type UserModel struct {
 	bun.BaseModel `bun:"table:users,alias:u"`
 
 	ID       int       `bun:"id,pk,autoincrement"`
-	Name     string    `bun:"name,notnull,unique"`
+	Name     string    `bun:"name,notnull"`
 	Password string    `bun:"password,notnull"`
-	Email    string    `bun:"email,notnull,unique"`
+	Email    string    `bun:"email,notnull"`
 	BirthDay time.Time `bun:"birth_day,notnull"`
+
+	EmailVerifiedAt time.Time `bun:"email_verified_at,nullzero"`
+	DisabledAt      time.Time `bun:"disabled_at,nullzero"`
+	DeletedAt       time.Time `bun:"deleted_at,soft_delete,nullzero"`
 }
 
+// names of the case-insensitive unique indexes of the users table
+const (
+	usersNameUniqueIndex  = "users_name_lower_key"
+	usersEmailUniqueIndex = "users_email_lower_key"
+)
+
 type UserRepository struct {
 	db *bun.DB
 }
@@ -28,10 +42,12 @@ func NewUserRepository(db *bun.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// IsExist reports whether the name is taken case-insensitively, including by deleted users who may be restored.
 func (ur *UserRepository) IsExist(ctx context.Context, name string) (bool, error) {
 	exists, err := ur.db.NewSelect().
 		Model((*UserModel)(nil)).
-		Where("name = ?", name).
+		Where("lower(name) = lower(?)", name).
+		WhereAllWithDeleted().
 		Exists(ctx)
 	if err != nil {
 		return true, err
@@ -44,12 +60,36 @@ func (ur *UserRepository) IsExist(ctx context.Context, name string) (bool, error
 	return false, nil
 }
 
-func (ur *UserRepository) Create(ctx context.Context, newUser domain.User) (*domain.User, error) {
+// IsEmailExist reports whether the email is taken case-insensitively, including by deleted users who may be restored.
+func (ur *UserRepository) IsEmailExist(ctx context.Context, email string) (bool, error) {
+	return ur.db.NewSelect().
+		Model((*UserModel)(nil)).
+		Where("lower(email) = lower(?)", email).
+		WhereAllWithDeleted().
+		Exists(ctx)
+}
+
+func (ur *UserRepository) Create(ctx context.Context, newUser domain.User, roles []domain.RoleName) (*domain.User, error) {
 	newUserModel := convertToUserModel(newUser)
-	_, err := ur.db.NewInsert().
-		Model(&newUserModel).
-		Returning("id").
-		Exec(ctx)
+	err := ur.db.RunInTx(ctx, nil, func(ctx context.Context, tx bun.Tx) error {
+		if _, err := tx.NewInsert().
+			Model(&newUserModel).
+			Returning("id").
+			Exec(ctx); err != nil {
+			// the name or email has been taken since it was checked
+			return convertUniqueViolation(err)
+		}
+
+		if len(roles) == 0 {
+			return nil
+		}
+		userRoleModels := make([]UserRoleModel, 0, len(roles))
+		for _, role := range roles {
+			userRoleModels = append(userRoleModels, UserRoleModel{UserID: newUserModel.ID, Role: string(role)})
+		}
+		_, err := tx.NewInsert().Model(&userRoleModels).Exec(ctx)
+		return err
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -72,9 +112,58 @@ func (ur *UserRepository) GetUserByID(ctx context.Context, userID domain.UserID)
 	return &user, nil
 }
 
-func (ur *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
+func (ur *UserRepository) GetUserByName(ctx context.Context, name string) (*domain.User, error) {
+	var userModel UserModel
+	if err := ur.db.NewSelect().Model(&userModel).Where("lower(name) = lower(?)", name).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	user := convertToUser(userModel)
+
+	return &user, nil
+}
+
+func (ur *UserRepository) GetUserByEmail(ctx context.Context, email string) (*domain.User, error) {
+	var userModel UserModel
+	if err := ur.db.NewSelect().Model(&userModel).Where("lower(email) = lower(?)", email).Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	user := convertToUser(userModel)
+
+	return &user, nil
+}
+
+func (ur *UserRepository) GetUsers(ctx context.Context, query usecase.UserListQuery) ([]domain.User, error) {
 	var userModels []UserModel
-	if err := ur.db.NewSelect().Model(&userModels).Scan(ctx); err != nil {
+	q := ur.db.NewSelect().Model(&userModels)
+	applyUserFilter(q, query.Filter)
+
+	// keyset pagination on (sort column, id)
+	order, op := "ASC", ">"
+	if query.Desc {
+		order, op = "DESC", "<"
+	}
+	column := bun.Ident(string(query.SortKey))
+	if query.After != nil {
+		if query.SortKey == usecase.UserSortByID {
+			q.Where("u.id "+op+" ?", query.After.ID)
+		} else {
+			q.Where("(u.?, u.id) "+op+" (?, ?)", column, query.After.Value, query.After.ID)
+		}
+	}
+	if query.SortKey != usecase.UserSortByID {
+		q.OrderExpr("u.? "+order, column)
+	}
+	q.OrderExpr("u.id " + order)
+
+	if err := q.Limit(query.Limit).Scan(ctx); err != nil {
 		return nil, err
 	}
 
@@ -83,6 +172,118 @@ func (ur *UserRepository) GetUsers(ctx context.Context) ([]domain.User, error) {
 	return users, nil
 }
 
+func (ur *UserRepository) CountUsers(ctx context.Context, filter usecase.UserFilter) (int, error) {
+	q := ur.db.NewSelect().Model((*UserModel)(nil))
+	applyUserFilter(q, filter)
+
+	return q.Count(ctx)
+}
+
+func applyUserFilter(q *bun.SelectQuery, filter usecase.UserFilter) {
+	if filter.NamePrefix != "" {
+		q.Where("u.name LIKE ?", escapeLike(filter.NamePrefix)+"%")
+	}
+	if filter.EmailDomain != "" {
+		q.Where("lower(u.email) LIKE ?", "%@"+escapeLike(strings.ToLower(filter.EmailDomain)))
+	}
+	if filter.BirthDayFrom != nil {
+		q.Where("u.birth_day >= ?", filter.BirthDayFrom.Format(domain.BirthDayLayout))
+	}
+	if filter.BirthDayTo != nil {
+		q.Where("u.birth_day <= ?", filter.BirthDayTo.Format(domain.BirthDayLayout))
+	}
+}
+
+// escapeLike escapes the wildcard characters of a LIKE pattern
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (ur *UserRepository) Update(ctx context.Context, user domain.User) error {
+	userModel := convertToUserModel(user)
+	_, err := ur.db.NewUpdate().
+		Model(&userModel).
+		Column("name", "email", "birth_day", "email_verified_at", "disabled_at").
+		WherePK().
+		Exec(ctx)
+	if err != nil {
+		// the name or email has been taken since it was checked
+		return convertUniqueViolation(err)
+	}
+	return nil
+}
+
+func (ur *UserRepository) UpdatePassword(ctx context.Context, userID domain.UserID, hashedPassword string) error {
+	_, err := ur.db.NewUpdate().
+		Model((*UserModel)(nil)).
+		Set("password = ?", hashedPassword).
+		Where("id = ?", userID).
+		Exec(ctx)
+	return err
+}
+
+// Delete soft-deletes the user; deleted users are ignored by the other queries.
+func (ur *UserRepository) Delete(ctx context.Context, userID domain.UserID) error {
+	_, err := ur.db.NewDelete().
+		Model((*UserModel)(nil)).
+		Where("id = ?", userID).
+		Exec(ctx)
+	return err
+}
+
+// Restore undeletes the user and reports whether a deleted user was found.
+func (ur *UserRepository) Restore(ctx context.Context, userID domain.UserID) (bool, error) {
+	res, err := ur.db.NewUpdate().
+		Model((*UserModel)(nil)).
+		WhereDeleted().
+		Set("deleted_at = NULL").
+		Where("id = ?", userID).
+		Exec(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// PurgeDeleted permanently deletes the users soft-deleted before the given time.
+func (ur *UserRepository) PurgeDeleted(ctx context.Context, before time.Time) (int, error) {
+	res, err := ur.db.NewDelete().
+		Model((*UserModel)(nil)).
+		WhereDeleted().
+		Where("deleted_at < ?", before).
+		ForceDelete().
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(n), nil
+}
+
+// convertUniqueViolation converts a violation of the unique indexes of the users table into the use case error.
+func convertUniqueViolation(err error) error {
+	var pgErr pgdriver.Error
+	if !errors.As(err, &pgErr) || pgErr.Field('C') != "23505" {
+		return err
+	}
+	switch pgErr.Field('n') {
+	case usersEmailUniqueIndex:
+		return fmt.Errorf("%w: %w", usecase.ErrEmailAlreadyExists, err)
+	case usersNameUniqueIndex:
+		return fmt.Errorf("%w: %w", usecase.ErrUserAlreadyExists, err)
+	}
+	return err
+}
+
 func convertToUserModel(user domain.User) UserModel {
 	return UserModel{
 		ID:       user.GetID().Int(),
@@ -90,18 +291,22 @@ func convertToUserModel(user domain.User) UserModel {
 		Password: user.GetPassword(),
 		Email:    user.GetEmail(),
 		BirthDay: user.GetBirthDay().Time(),
+
+		EmailVerifiedAt: user.GetEmailVerifiedAt(),
+		DisabledAt:      user.GetDisabledAt(),
 	}
 }
 
 func convertToUser(userModel UserModel) domain.User {
-	user := domain.NewUser(
+	user := domain.ReconstructUser(
+		domain.UserID(userModel.ID),
 		userModel.Name,
 		userModel.Password,
 		userModel.Email,
 		userModel.BirthDay,
+		userModel.EmailVerifiedAt,
 	)
-	user.SetID(userModel.ID)
-
+	user.SetDisabledAt(userModel.DisabledAt)
 	return user
 }
 
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/uptrace/bun"
+)
+
+type UserTokenModel struct {
+	bun.BaseModel `bun:"table:user_tokens,alias:ut"`
+
+	ID        int       `bun:"id,pk,autoincrement"`
+	UserID    int       `bun:"user_id,notnull"`
+	Purpose   string    `bun:"purpose,notnull"`
+	TokenHash string    `bun:"token_hash,notnull,unique"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+	UsedAt    time.Time `bun:"used_at,nullzero"`
+}
+
+type UserTokenRepository struct {
+	db *bun.DB
+}
+
+func NewUserTokenRepository(db *bun.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (tr *UserTokenRepository) Create(ctx context.Context, token domain.UserToken) error {
+	tokenModel := UserTokenModel{
+		UserID:    token.GetUserID().Int(),
+		Purpose:   string(token.GetPurpose()),
+		TokenHash: token.GetTokenHash(),
+		ExpiresAt: token.GetExpiresAt(),
+	}
+	_, err := tr.db.NewInsert().Model(&tokenModel).Exec(ctx)
+	return err
+}
+
+func (tr *UserTokenRepository) Consume(ctx context.Context, purpose domain.UserTokenPurpose, tokenHash string) (*domain.UserID, error) {
+	// a single statement, so that a token can be used only once even under concurrent requests
+	var userID int
+	err := tr.db.NewUpdate().
+		Model((*UserTokenModel)(nil)).
+		Set("used_at = ?", time.Now()).
+		Where("token_hash = ?", tokenHash).
+		Where("purpose = ?", string(purpose)).
+		Where("used_at IS NULL").
+		Where("expires_at > ?", time.Now()).
+		Returning("user_id").
+		Scan(ctx, &userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	id := domain.UserID(userID)
+	return &id, nil
+}
+
+func (tr *UserTokenRepository) DeleteByUserID(ctx context.Context, userID domain.UserID, purpose domain.UserTokenPurpose) error {
+	_, err := tr.db.NewDelete().
+		Model((*UserTokenModel)(nil)).
+		Where("user_id = ?", userID).
+		Where("purpose = ?", string(purpose)).
+		Exec(ctx)
+	return err
+}
+
+// LatestCreatedAt returns when the last token was issued to the user, or the zero time if there is none.
+func (tr *UserTokenRepository) LatestCreatedAt(ctx context.Context, userID domain.UserID, purpose domain.UserTokenPurpose) (time.Time, error) {
+	var createdAt bun.NullTime
+	err := tr.db.NewSelect().
+		Model((*UserTokenModel)(nil)).
+		ColumnExpr("MAX(created_at)").
+		Where("user_id = ?", userID).
+		Where("purpose = ?", string(purpose)).
+		Scan(ctx, &createdAt)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return createdAt.Time, nil
+}
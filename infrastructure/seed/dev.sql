@@ -0,0 +1,53 @@
+-- users for development (passwords are bcrypt hashes of example01, example02 and example03, emails are verified)
+INSERT INTO
+    users (name, password, email, birth_day, email_verified_at)
+VALUES
+    (
+        'user01',
+        '$2a$10$KioT4TFeiGvJwd7Edhl7HOJKlQXjU2kpYSVsnpSZ1/qvvyYW3N312',
+        'example01@example.com',
+        '2001-01-01',
+        CURRENT_TIMESTAMP
+    ),
+    (
+        'user02',
+        '$2a$10$ZKAcqF/SAB3v4rfOgcIh2OFmhs62Wrw0Qh91tfACu0ug3qst1.NWq',
+        'example02@example.com',
+        '2002-01-01',
+        CURRENT_TIMESTAMP
+    ),
+    (
+        'user03',
+        '$2a$10$ruXMwESRuaAZGlJDUOVUqOgkX27cjYAZ3Uyypsfhlmze2z72tWJa2',
+        'example03@example.com',
+        '2003-01-01',
+        CURRENT_TIMESTAMP
+    )
+ON CONFLICT DO NOTHING;
+
+--bun:split
+
+-- every user is a member, user01 is also an admin
+INSERT INTO
+    user_roles (user_id, role)
+SELECT
+    id,
+    'member'
+FROM
+    users
+WHERE
+    name IN ('user01', 'user02', 'user03')
+ON CONFLICT DO NOTHING;
+
+--bun:split
+
+INSERT INTO
+    user_roles (user_id, role)
+SELECT
+    id,
+    'admin'
+FROM
+    users
+WHERE
+    name = 'user01'
+ON CONFLICT DO NOTHING;
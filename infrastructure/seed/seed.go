@@ -0,0 +1,19 @@
+// Package seed inserts example data for development into a migrated database.
+package seed
+
+import (
+	"context"
+	_ "embed"
+	"strings"
+
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/migrate"
+)
+
+//go:embed dev.sql
+var devSQL string
+
+// Dev inserts the development users and their roles. Rows that already exist are left as they are, so it can be run repeatedly.
+func Dev(ctx context.Context, db *bun.DB) error {
+	return migrate.Exec(ctx, db, strings.NewReader(devSQL), true)
+}
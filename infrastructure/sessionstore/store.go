@@ -0,0 +1,211 @@
+package sessionstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base32"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/uptrace/bun"
+)
+
+var base32RawStdEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type SessionModel struct {
+	bun.BaseModel `bun:"table:sessions,alias:s"`
+
+	ID        string    `bun:"id,pk"`
+	UserID    int       `bun:"user_id,nullzero"`
+	Data      []byte    `bun:"data,notnull"`
+	CreatedAt time.Time `bun:"created_at,notnull,default:current_timestamp"`
+	ExpiresAt time.Time `bun:"expires_at,notnull"`
+}
+
+// Store is a sessions.Store that keeps session values in the sessions table.
+// The cookie only carries the signed session ID, so a session can be revoked on the server side.
+type Store struct {
+	Codecs  []securecookie.Codec
+	Options *sessions.Options
+
+	// UserIDKey is the key of the int session value holding the user ID.
+	// It is stored in its own column so that all sessions of a user can be revoked.
+	UserIDKey interface{}
+
+	db         *bun.DB
+	serializer securecookie.GobEncoder
+}
+
+func NewStore(db *bun.DB, keyPairs ...[]byte) *Store {
+	s := &Store{
+		Codecs: securecookie.CodecsFromPairs(keyPairs...),
+		Options: &sessions.Options{
+			Path:     "/",
+			MaxAge:   86400 * 30,
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		},
+		db: db,
+	}
+
+	s.MaxAge(s.Options.MaxAge)
+	return s
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *Store) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name without adding it to the registry.
+// A cookie that cannot be decoded or refers to an expired or revoked session
+// results in a new empty session.
+func (s *Store) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.Options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.Codecs...); err != nil {
+		session.ID = ""
+		return session, nil
+	}
+
+	found, err := s.load(r.Context(), session)
+	if err != nil {
+		return session, err
+	}
+	if !found {
+		// never resurrect an ID that the server does not know
+		session.ID = ""
+		return session, nil
+	}
+	session.IsNew = false
+
+	return session, nil
+}
+
+// Save persists the session and writes the signed session ID to the cookie.
+// If Options.MaxAge is <= 0 the session is deleted from the table.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	ctx := r.Context()
+
+	// Delete if max-age is <= 0
+	if session.Options.MaxAge <= 0 {
+		if session.ID != "" {
+			if err := s.Revoke(ctx, session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		session.ID = base32RawStdEncoding.EncodeToString(securecookie.GenerateRandomKey(32))
+	}
+	if err := s.save(ctx, session); err != nil {
+		return err
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.Codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+
+	return nil
+}
+
+// MaxAge sets the maximum age for the store and the underlying cookie implementation.
+func (s *Store) MaxAge(age int) {
+	s.Options.MaxAge = age
+
+	for _, codec := range s.Codecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(age)
+		}
+	}
+}
+
+// Revoke deletes the session so that its cookie can no longer be used.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	_, err := s.db.NewDelete().
+		Model((*SessionModel)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+	return err
+}
+
+// RevokeByUserID deletes all sessions of the user.
+func (s *Store) RevokeByUserID(ctx context.Context, userID domain.UserID) error {
+	_, err := s.db.NewDelete().
+		Model((*SessionModel)(nil)).
+		Where("user_id = ?", userID).
+		Exec(ctx)
+	return err
+}
+
+// DeleteExpired deletes the expired sessions and returns the number of deleted sessions.
+func (s *Store) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := s.db.NewDelete().
+		Model((*SessionModel)(nil)).
+		Where("expires_at <= ?", time.Now()).
+		Exec(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+func (s *Store) save(ctx context.Context, session *sessions.Session) error {
+	data, err := s.serializer.Serialize(session.Values)
+	if err != nil {
+		return err
+	}
+
+	sessionModel := SessionModel{
+		ID:        session.ID,
+		Data:      data,
+		ExpiresAt: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second),
+	}
+	if s.UserIDKey != nil {
+		sessionModel.UserID, _ = session.Values[s.UserIDKey].(int)
+	}
+	_, err = s.db.NewInsert().
+		Model(&sessionModel).
+		On("CONFLICT (id) DO UPDATE").
+		Set("user_id = EXCLUDED.user_id").
+		Set("data = EXCLUDED.data").
+		Set("expires_at = EXCLUDED.expires_at").
+		Exec(ctx)
+	return err
+}
+
+func (s *Store) load(ctx context.Context, session *sessions.Session) (bool, error) {
+	var sessionModel SessionModel
+	if err := s.db.NewSelect().
+		Model(&sessionModel).
+		Where("id = ?", session.ID).
+		Where("expires_at > ?", time.Now()).
+		Scan(ctx); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	if err := s.serializer.Deserialize(sessionModel.Data, &session.Values); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,230 @@
+package sessionstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/ricky2122/go-echo-example/infrastructure/sessionstore"
+	"github.com/stretchr/testify/assert"
+	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/dialect/pgdialect"
+)
+
+// TestStubConn is a database connection that records the queries,
+// and answers every SELECT with the rows of the sessions table.
+type TestStubConn struct {
+	queries  []string
+	sessions [][]driver.Value
+}
+
+func (c *TestStubConn) Connect(context.Context) (driver.Conn, error) { return c, nil }
+func (c *TestStubConn) Driver() driver.Driver                        { return nil }
+func (c *TestStubConn) Prepare(string) (driver.Stmt, error)          { return nil, driver.ErrSkip }
+func (c *TestStubConn) Begin() (driver.Tx, error)                    { return nil, driver.ErrSkip }
+func (c *TestStubConn) Close() error                                 { return nil }
+
+func (c *TestStubConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.queries = append(c.queries, query)
+	return driver.RowsAffected(1), nil
+}
+
+func (c *TestStubConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.queries = append(c.queries, query)
+	if strings.HasPrefix(query, "SELECT") {
+		return &TestStubRows{columns: []string{"id", "user_id", "data", "created_at", "expires_at"}, rows: c.sessions}, nil
+	}
+	// the RETURNING clause of the INSERT
+	return &TestStubRows{columns: []string{"created_at"}, rows: [][]driver.Value{{time.Now()}}}, nil
+}
+
+type TestStubRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *TestStubRows) Columns() []string { return r.columns }
+func (r *TestStubRows) Close() error      { return nil }
+
+func (r *TestStubRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
+
+func TestStore(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	newStore := func(conn *TestStubConn) *sessionstore.Store {
+		s := sessionstore.NewStore(bun.NewDB(sql.OpenDB(conn), pgdialect.New()), key)
+		s.UserIDKey = "user_id"
+		return s
+	}
+	newRequest := func(cookie *http.Cookie) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		return req
+	}
+	encode := func(t *testing.T, codecs []securecookie.Codec, id string) *http.Cookie {
+		value, err := securecookie.EncodeMulti("session", id, codecs...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &http.Cookie{Name: "session", Value: value}
+	}
+
+	t.Run("Save a new session", func(t *testing.T) {
+		conn := &TestStubConn{}
+		s := newStore(conn)
+		s.MaxAge(3600)
+
+		req := newRequest(nil)
+		session, err := s.New(req, "session")
+		if !assert.NoError(t, err) {
+			return
+		}
+		session.Values["user_id"] = 1
+
+		rec := httptest.NewRecorder()
+		if !assert.NoError(t, s.Save(req, rec, session)) {
+			return
+		}
+		assert.NotEmpty(t, session.ID)
+
+		// the cookie carries the signed session ID only
+		cookies := rec.Result().Cookies()
+		if !assert.Len(t, cookies, 1) {
+			return
+		}
+		assert.Equal(t, 3600, cookies[0].MaxAge)
+		var id string
+		if assert.NoError(t, securecookie.DecodeMulti("session", cookies[0].Value, &id, s.Codecs...)) {
+			assert.Equal(t, session.ID, id)
+		}
+
+		// the row expires with the cookie
+		if !assert.Len(t, conn.queries, 1) {
+			return
+		}
+		assert.Contains(t, conn.queries[0], "'"+session.ID+"', 1, ")
+		m := regexp.MustCompile(`'([0-9-]+ [0-9:.]+\+00:00)'\) ON CONFLICT`).FindStringSubmatch(conn.queries[0])
+		if assert.Len(t, m, 2) {
+			expiresAt, err := time.Parse("2006-01-02 15:04:05.999999-07:00", m[1])
+			if assert.NoError(t, err) {
+				assert.WithinDuration(t, time.Now().Add(time.Hour), expiresAt, 10*time.Second)
+			}
+		}
+	})
+
+	t.Run("Load a stored session", func(t *testing.T) {
+		data, err := securecookie.GobEncoder{}.Serialize(map[interface{}]interface{}{"user_id": 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+		conn := &TestStubConn{sessions: [][]driver.Value{{"id01", int64(1), data, time.Now(), time.Now().Add(time.Hour)}}}
+		s := newStore(conn)
+
+		session, err := s.New(newRequest(encode(t, s.Codecs, "id01")), "session")
+		if assert.NoError(t, err) {
+			assert.False(t, session.IsNew)
+			assert.Equal(t, "id01", session.ID)
+			assert.Equal(t, 1, session.Values["user_id"])
+		}
+
+		// expired sessions are not loaded
+		if assert.Len(t, conn.queries, 1) {
+			assert.Contains(t, conn.queries[0], "(id = 'id01') AND (expires_at > ")
+		}
+	})
+
+	t.Run("Expired or revoked session", func(t *testing.T) {
+		conn := &TestStubConn{}
+		s := newStore(conn)
+
+		session, err := s.New(newRequest(encode(t, s.Codecs, "id01")), "session")
+		if assert.NoError(t, err) {
+			assert.True(t, session.IsNew)
+			assert.Empty(t, session.ID, "an unknown ID is not reused")
+		}
+	})
+
+	t.Run("Cookie signed with another key", func(t *testing.T) {
+		conn := &TestStubConn{}
+		s := newStore(conn)
+		other := securecookie.CodecsFromPairs([]byte("fedcba9876543210fedcba9876543210"))
+
+		session, err := s.New(newRequest(encode(t, other, "id01")), "session")
+		if assert.NoError(t, err) {
+			assert.True(t, session.IsNew)
+			assert.Empty(t, session.ID)
+		}
+		assert.Empty(t, conn.queries)
+	})
+
+	cases := []struct {
+		name             string
+		id               string
+		maxAge           int
+		wantQueries      []string
+		wantCookieMaxAge int
+	}{
+		{
+			name:             "Negative MaxAge deletes the session",
+			id:               "id01",
+			maxAge:           -1,
+			wantQueries:      []string{`DELETE FROM "sessions" AS "s" WHERE (id = 'id01')`},
+			wantCookieMaxAge: -1,
+		},
+		{
+			name:        "Zero MaxAge deletes the session",
+			id:          "id01",
+			maxAge:      0,
+			wantQueries: []string{`DELETE FROM "sessions" AS "s" WHERE (id = 'id01')`},
+		},
+		{
+			name:             "Unsaved session is not deleted",
+			maxAge:           -1,
+			wantCookieMaxAge: -1,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &TestStubConn{}
+			s := newStore(conn)
+
+			req := newRequest(nil)
+			session, err := s.New(req, "session")
+			if !assert.NoError(t, err) {
+				return
+			}
+			session.ID = tt.id
+			session.Options.MaxAge = tt.maxAge
+
+			rec := httptest.NewRecorder()
+			if !assert.NoError(t, s.Save(req, rec, session)) {
+				return
+			}
+			assert.Equal(t, tt.wantQueries, conn.queries)
+
+			// the cookie is cleared
+			cookies := rec.Result().Cookies()
+			if assert.Len(t, cookies, 1) {
+				assert.Empty(t, cookies[0].Value)
+				assert.Equal(t, tt.wantCookieMaxAge, cookies[0].MaxAge)
+			}
+		})
+	}
+}
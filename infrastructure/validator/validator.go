@@ -0,0 +1,152 @@
+package validator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/ja"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator/v10"
+	en_translations "github.com/go-playground/validator/v10/translations/en"
+	ja_translations "github.com/go-playground/validator/v10/translations/ja"
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+// customTranslations are the messages of the custom rules for each locale
+var customTranslations = map[string]map[string]string{
+	"en": {
+		"username": "{0} must be up to 32 letters, digits, '_', '-' or '.'",
+		"birthday": "{0} must be a past date in YYYY-MM-DD format",
+	},
+	"ja": {
+		"username": "{0}は32文字以内の英数字、'_'、'-'、'.'で入力してください",
+		"birthday": "{0}はYYYY-MM-DD形式の過去の日付で入力してください",
+	},
+}
+
+type CustomValidator struct {
+	validator  *validator.Validate
+	translator *ut.UniversalTranslator
+}
+
+func NewCustomValidator() *CustomValidator {
+	v := validator.New()
+
+	// report fields by the names used in the request
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, key := range []string{"json", "query", "param"} {
+			name, _, _ := strings.Cut(field.Tag.Get(key), ",")
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+
+	// custom rules
+	_ = v.RegisterValidation("username", validateUserName)
+	_ = v.RegisterValidation("birthday", validateBirthDay)
+
+	// translations, falling back to English
+	enLocale := en.New()
+	translator := ut.New(enLocale, enLocale, ja.New())
+	enTrans, _ := translator.GetTranslator("en")
+	_ = en_translations.RegisterDefaultTranslations(v, enTrans)
+	jaTrans, _ := translator.GetTranslator("ja")
+	_ = ja_translations.RegisterDefaultTranslations(v, jaTrans)
+	for locale, messages := range customTranslations {
+		trans, _ := translator.GetTranslator(locale)
+		for tag, message := range messages {
+			_ = v.RegisterTranslation(tag, trans, registerTranslation(tag, message), translate)
+		}
+	}
+
+	return &CustomValidator{validator: v, translator: translator}
+}
+
+func (cv *CustomValidator) Validate(i interface{}) error {
+	err := cv.validator.Struct(i)
+	if err == nil {
+		return nil
+	}
+
+	var validationErrors validator.ValidationErrors
+	if !errors.As(err, &validationErrors) {
+		return err
+	}
+	return &ValidationError{errors: validationErrors, translator: cv.translator}
+}
+
+// ValidationError holds the failed rules of a request; the messages are translated
+// into the language of the request by the error handler.
+type ValidationError struct {
+	errors     validator.ValidationErrors
+	translator *ut.UniversalTranslator
+}
+
+func (e *ValidationError) Error() string {
+	return e.errors.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.errors
+}
+
+// FieldErrors returns the failed rules with messages in the first supported language of the Accept-Language header.
+func (e *ValidationError) FieldErrors(acceptLanguage string) []controller.FieldError {
+	trans, _ := e.translator.FindTranslator(parseAcceptLanguage(acceptLanguage)...)
+
+	fields := make([]controller.FieldError, 0, len(e.errors))
+	for _, fe := range e.errors {
+		fields = append(fields, controller.FieldError{
+			Field:   fe.Field(),
+			Rule:    fe.Tag(),
+			Message: fe.Translate(trans),
+		})
+	}
+	return fields
+}
+
+// parseAcceptLanguage returns the languages of the header in order, e.g. "ja-JP,en;q=0.8" is ["ja", "en"].
+func parseAcceptLanguage(header string) []string {
+	var locales []string
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(part, ";")
+		lang, _, _ := strings.Cut(strings.TrimSpace(tag), "-")
+		if lang != "" && lang != "*" {
+			locales = append(locales, strings.ToLower(lang))
+		}
+	}
+	return locales
+}
+
+func validateUserName(fl validator.FieldLevel) bool {
+	_, err := domain.NewUserName(fl.Field().String())
+	return err == nil
+}
+
+func validateBirthDay(fl validator.FieldLevel) bool {
+	birthDay, err := time.Parse(domain.BirthDayLayout, fl.Field().String())
+	return err == nil && !birthDay.After(time.Now())
+}
+
+func registerTranslation(tag, message string) validator.RegisterTranslationsFunc {
+	return func(trans ut.Translator) error {
+		return trans.Add(tag, message, true)
+	}
+}
+
+func translate(trans ut.Translator, fe validator.FieldError) string {
+	message, err := trans.T(fe.Tag(), fe.Field())
+	if err != nil {
+		return fe.Error()
+	}
+	return message
+}
@@ -0,0 +1,71 @@
+package validator_test
+
+import (
+	"testing"
+
+	"github.com/ricky2122/go-echo-example/controller"
+	"github.com/ricky2122/go-echo-example/infrastructure/validator"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Name     string `json:"name" validate:"required,username"`
+	BirthDay string `json:"birth_day" validate:"required,birthday"`
+}
+
+func TestCustomValidator(t *testing.T) {
+	cv := validator.NewCustomValidator()
+
+	cases := []struct {
+		name           string
+		req            testRequest
+		acceptLanguage string
+		want           []controller.FieldError
+	}{
+		{
+			name: "valid",
+			req:  testRequest{Name: "user_01", BirthDay: "2001-01-01"},
+		},
+		{
+			name:           "english",
+			req:            testRequest{Name: "user 01", BirthDay: "2999-01-01"},
+			acceptLanguage: "en-US,en;q=0.9",
+			want: []controller.FieldError{
+				{Field: "name", Rule: "username", Message: "name must be up to 32 letters, digits, '_', '-' or '.'"},
+				{Field: "birth_day", Rule: "birthday", Message: "birth_day must be a past date in YYYY-MM-DD format"},
+			},
+		},
+		{
+			name:           "japanese",
+			req:            testRequest{Name: "", BirthDay: "2001-13-01"},
+			acceptLanguage: "ja-JP,ja;q=0.9,en;q=0.8",
+			want: []controller.FieldError{
+				{Field: "name", Rule: "required", Message: "nameは必須フィールドです"},
+				{Field: "birth_day", Rule: "birthday", Message: "birth_dayはYYYY-MM-DD形式の過去の日付で入力してください"},
+			},
+		},
+		{
+			name:           "unsupported language falls back to english",
+			req:            testRequest{Name: "user01234567890123456789012345678", BirthDay: "2001-01-01"},
+			acceptLanguage: "fr",
+			want: []controller.FieldError{
+				{Field: "name", Rule: "username", Message: "name must be up to 32 letters, digits, '_', '-' or '.'"},
+			},
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := cv.Validate(&tt.req)
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var te controller.TranslatableError
+			if assert.ErrorAs(t, err, &te) {
+				assert.Equal(t, tt.want, te.FieldErrors(tt.acceptLanguage))
+			}
+		})
+	}
+}
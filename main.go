@@ -1,19 +1,21 @@
 package main
 
 import (
-	"github.com/ricky2122/go-echo-example/infrastructure"
-	"github.com/ricky2122/go-echo-example/infrastructure/api"
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ricky2122/go-echo-example/infrastructure/cli"
 )
 
 func main() {
-	db := infrastructure.NewDB(infrastructure.DBConfig{
-		Host:     "localhost",
-		Port:     "15432",
-		DBName:   "echo_example",
-		User:     "root",
-		Password: "password",
-	})
-	router := api.NewRouter(db)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
 
-	router.Logger.Fatal(router.Start(":1323"))
+	if err := cli.Run(ctx, os.Args[1:]); err != nil {
+		stop()
+		log.Fatal(err)
+	}
 }
@@ -0,0 +1,318 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+var (
+	ErrInvalidAccessToken   = errors.New("invalid or expired access token")
+	ErrUnsupportedGrantType = errors.New("unsupported grant type")
+	ErrSessionNotFound      = errors.New("session not found")
+)
+
+// maxDeviceLength is the length of the stored User-Agent, which is only shown to the user.
+const maxDeviceLength = 255
+
+// grant types of IssueToken, named after OAuth 2.0
+const (
+	GrantTypePassword     = "password"
+	GrantTypeRefreshToken = "refresh_token"
+)
+
+type AccessTokenClaims struct {
+	UserID    domain.UserID
+	IssuedAt  time.Time
+	ExpiresAt time.Time
+}
+
+// AccessTokenSigner signs the access tokens, which are verified without the database.
+type AccessTokenSigner interface {
+	Sign(claims AccessTokenClaims) (string, error)
+	// Verify returns ErrInvalidAccessToken unless the token is well-formed, unexpired and signed with a known key.
+	Verify(token string) (*AccessTokenClaims, error)
+}
+
+type IRefreshTokenRepository interface {
+	Create(ctx context.Context, token domain.RefreshToken) error
+	// GetByTokenHash returns the token, or nil if there is no such token.
+	GetByTokenHash(ctx context.Context, tokenHash string) (*domain.RefreshToken, error)
+	// MarkUsed marks the unused and unrevoked token as used and reports whether it did,
+	// so that a token can be rotated only once even under concurrent requests.
+	MarkUsed(ctx context.Context, tokenHash string) (bool, error)
+	// GetActiveByUserID returns the unused, unrevoked and unexpired token of each family of the user, newest first.
+	GetActiveByUserID(ctx context.Context, userID domain.UserID) ([]domain.RefreshToken, error)
+	// RevokeFamily revokes the tokens of the family of the user and reports whether it had an active token.
+	RevokeFamily(ctx context.Context, userID domain.UserID, familyID string) (bool, error)
+	RevokeByUserID(ctx context.Context, userID domain.UserID) error
+}
+
+// Authenticator verifies the name and password of a user, e.g. AuthUseCase.
+type Authenticator interface {
+	Login(ctx context.Context, input LoginUseCaseInput) (domain.UserID, error)
+}
+
+type IssueTokenUseCaseInput struct {
+	GrantType string
+	// Name and Password are used by the password grant
+	Name     string
+	Password string
+	// RefreshToken is used by the refresh_token grant
+	RefreshToken string
+	// Device is the User-Agent of the client, shown in the list of sessions
+	Device string
+	// IP is the client IP, which failed logins of the password grant are counted by
+	IP string
+}
+
+type IssueTokenUseCaseOutput struct {
+	AccessToken  string
+	ExpiresIn    time.Duration
+	RefreshToken string
+}
+
+type RevokeTokenUseCaseInput struct {
+	RefreshToken string
+}
+
+type GetSessionsUseCaseInput struct {
+	UserID int
+}
+
+type GetSessionsUseCaseOutput struct {
+	Sessions []Session
+}
+
+// Session is a device logged in with a refresh token.
+type Session struct {
+	ID         string
+	Device     string
+	AuthTime   time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+}
+
+type RevokeSessionUseCaseInput struct {
+	UserID    int
+	SessionID string
+}
+
+type VerifyAccessTokenUseCaseInput struct {
+	AccessToken string
+}
+
+type VerifyAccessTokenUseCaseOutput struct {
+	UserID int
+}
+
+type TokenUseCaseConfig struct {
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+}
+
+// TokenUseCase issues bearer tokens to the clients that cannot keep a session cookie.
+type TokenUseCase struct {
+	auth Authenticator
+	ur   IUserRepository
+	rtr  IRefreshTokenRepository
+	// sr revokes every session of a user whose refresh token has leaked
+	sr   ISessionRepository
+	ats  AccessTokenSigner
+	conf TokenUseCaseConfig
+}
+
+func NewTokenUseCase(
+	auth Authenticator,
+	ur IUserRepository,
+	rtr IRefreshTokenRepository,
+	sr ISessionRepository,
+	ats AccessTokenSigner,
+	conf TokenUseCaseConfig,
+) *TokenUseCase {
+	return &TokenUseCase{auth: auth, ur: ur, rtr: rtr, sr: sr, ats: ats, conf: conf}
+}
+
+// IssueToken returns a new access token and refresh token for the credentials of the grant type.
+// The password grant starts a new session, and the refresh_token grant replaces the refresh token within its session.
+func (tu *TokenUseCase) IssueToken(ctx context.Context, input IssueTokenUseCaseInput) (*IssueTokenUseCaseOutput, error) {
+	var refreshToken func(tokenHash string, expiresAt time.Time) domain.RefreshToken
+	switch input.GrantType {
+	case GrantTypePassword:
+		// the same checks as the session login
+		loginInput := LoginUseCaseInput{Name: input.Name, Password: input.Password, IP: input.IP}
+		userID, err := tu.auth.Login(ctx, loginInput)
+		if err != nil {
+			return nil, err
+		}
+		familyID, _, err := generateToken()
+		if err != nil {
+			return nil, err
+		}
+		authTime := time.Now()
+		refreshToken = func(tokenHash string, expiresAt time.Time) domain.RefreshToken {
+			return domain.NewRefreshToken(userID, familyID, tokenHash, truncate(input.Device, maxDeviceLength), authTime, expiresAt)
+		}
+	case GrantTypeRefreshToken:
+		used, err := tu.useRefreshToken(ctx, input.RefreshToken)
+		if err != nil {
+			return nil, err
+		}
+		refreshToken = used.Rotate
+	default:
+		return nil, ErrUnsupportedGrantType
+	}
+
+	return tu.issue(ctx, refreshToken)
+}
+
+// RevokeToken ends the session of the refresh token, for logging out.
+// It succeeds even if the token is unknown, as there is nothing left to revoke.
+func (tu *TokenUseCase) RevokeToken(ctx context.Context, input RevokeTokenUseCaseInput) error {
+	token, err := tu.rtr.GetByTokenHash(ctx, hashToken(input.RefreshToken))
+	if err != nil || token == nil {
+		return err
+	}
+	_, err = tu.rtr.RevokeFamily(ctx, token.GetUserID(), token.GetFamilyID())
+	return err
+}
+
+// GetSessions returns the devices logged in with refresh tokens, most recently used first.
+func (tu *TokenUseCase) GetSessions(ctx context.Context, input GetSessionsUseCaseInput) (*GetSessionsUseCaseOutput, error) {
+	tokens, err := tu.rtr.GetActiveByUserID(ctx, domain.UserID(input.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]Session, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, Session{
+			ID:         token.GetFamilyID(),
+			Device:     token.GetDevice(),
+			AuthTime:   token.GetAuthTime(),
+			LastUsedAt: token.GetCreatedAt(),
+			ExpiresAt:  token.GetExpiresAt(),
+		})
+	}
+	return &GetSessionsUseCaseOutput{Sessions: sessions}, nil
+}
+
+// RevokeSession logs the device out. Its access tokens stay valid until they expire.
+func (tu *TokenUseCase) RevokeSession(ctx context.Context, input RevokeSessionUseCaseInput) error {
+	revoked, err := tu.rtr.RevokeFamily(ctx, domain.UserID(input.UserID), input.SessionID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// VerifyAccessToken returns the user the access token was issued to.
+func (tu *TokenUseCase) VerifyAccessToken(_ context.Context, input VerifyAccessTokenUseCaseInput) (*VerifyAccessTokenUseCaseOutput, error) {
+	claims, err := tu.ats.Verify(input.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	return &VerifyAccessTokenUseCaseOutput{UserID: claims.UserID.Int()}, nil
+}
+
+// useRefreshToken marks the refresh token as used and returns it, if its user may still log in.
+// A token that has already been used has leaked, as the client has received its replacement,
+// so every session of the user is revoked to log out whoever holds the stolen token,
+// even after the token has expired or its family has ended.
+func (tu *TokenUseCase) useRefreshToken(ctx context.Context, refreshToken string) (*domain.RefreshToken, error) {
+	if refreshToken == "" {
+		return nil, ErrInvalidToken
+	}
+	tokenHash := hashToken(refreshToken)
+	token, err := tu.rtr.GetByTokenHash(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if token == nil {
+		return nil, ErrInvalidToken
+	}
+	if token.IsUsed() {
+		return nil, tu.revokeLeakedToken(ctx, token)
+	}
+	if token.IsRevoked() || token.IsExpired(time.Now()) {
+		return nil, ErrInvalidToken
+	}
+
+	// lost to a concurrent request with the same token, e.g. a retry of the client, which is not a leak
+	marked, err := tu.rtr.MarkUsed(ctx, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	if !marked {
+		return nil, ErrInvalidToken
+	}
+
+	user, err := tu.ur.GetUserByID(ctx, token.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.IsDisabled() {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
+
+// revokeLeakedToken revokes the family of the reused token and all sessions of its user,
+// and returns the error to report to the client.
+func (tu *TokenUseCase) revokeLeakedToken(ctx context.Context, token *domain.RefreshToken) error {
+	if _, err := tu.rtr.RevokeFamily(ctx, token.GetUserID(), token.GetFamilyID()); err != nil {
+		return err
+	}
+	if err := tu.sr.RevokeByUserID(ctx, token.GetUserID()); err != nil {
+		return err
+	}
+	return ErrInvalidToken
+}
+
+func (tu *TokenUseCase) issue(
+	ctx context.Context,
+	refreshToken func(tokenHash string, expiresAt time.Time) domain.RefreshToken,
+) (*IssueTokenUseCaseOutput, error) {
+	now := time.Now()
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return nil, err
+	}
+	stored := refreshToken(tokenHash, now.Add(tu.conf.RefreshTokenTTL))
+	if err := tu.rtr.Create(ctx, stored); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := tu.ats.Sign(AccessTokenClaims{
+		UserID:    stored.GetUserID(),
+		IssuedAt:  now,
+		ExpiresAt: now.Add(tu.conf.AccessTokenTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	output := &IssueTokenUseCaseOutput{
+		AccessToken:  accessToken,
+		ExpiresIn:    tu.conf.AccessTokenTTL,
+		RefreshToken: token,
+	}
+	return output, nil
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 character.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
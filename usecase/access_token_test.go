@@ -0,0 +1,420 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubRefreshTokenRepository struct {
+	tokenStore []domain.RefreshToken
+	used       map[string]bool
+	revoked    map[string]bool
+	// raced makes MarkUsed lose to a concurrent request with the same token
+	raced bool
+}
+
+func (s *TestStubRefreshTokenRepository) Create(_ context.Context, token domain.RefreshToken) error {
+	s.tokenStore = append(s.tokenStore, token)
+	return nil
+}
+
+func (s *TestStubRefreshTokenRepository) GetByTokenHash(_ context.Context, tokenHash string) (*domain.RefreshToken, error) {
+	for _, token := range s.tokenStore {
+		if token.GetTokenHash() == tokenHash {
+			stored := s.reconstruct(token)
+			return &stored, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *TestStubRefreshTokenRepository) MarkUsed(_ context.Context, tokenHash string) (bool, error) {
+	if s.raced || s.used[tokenHash] || s.revoked[tokenHash] {
+		return false, nil
+	}
+	if s.used == nil {
+		s.used = map[string]bool{}
+	}
+	s.used[tokenHash] = true
+	return true, nil
+}
+
+func (s *TestStubRefreshTokenRepository) GetActiveByUserID(_ context.Context, userID domain.UserID) ([]domain.RefreshToken, error) {
+	tokens := []domain.RefreshToken{}
+	for _, token := range s.tokenStore {
+		if token.GetUserID() == userID && s.isActive(token) {
+			tokens = append([]domain.RefreshToken{s.reconstruct(token)}, tokens...)
+		}
+	}
+	return tokens, nil
+}
+
+func (s *TestStubRefreshTokenRepository) RevokeFamily(_ context.Context, userID domain.UserID, familyID string) (bool, error) {
+	active := false
+	for _, token := range s.tokenStore {
+		if token.GetUserID() == userID && token.GetFamilyID() == familyID {
+			active = active || s.isActive(token)
+			s.revoke(token.GetTokenHash())
+		}
+	}
+	return active, nil
+}
+
+func (s *TestStubRefreshTokenRepository) RevokeByUserID(_ context.Context, userID domain.UserID) error {
+	for _, token := range s.tokenStore {
+		if token.GetUserID() == userID {
+			s.revoke(token.GetTokenHash())
+		}
+	}
+	return nil
+}
+
+func (s *TestStubRefreshTokenRepository) isActive(token domain.RefreshToken) bool {
+	return !s.used[token.GetTokenHash()] && !s.revoked[token.GetTokenHash()] && !token.IsExpired(time.Now())
+}
+
+func (s *TestStubRefreshTokenRepository) revoke(tokenHash string) {
+	if s.revoked == nil {
+		s.revoked = map[string]bool{}
+	}
+	s.revoked[tokenHash] = true
+}
+
+// reconstruct returns the token with its stored state.
+func (s *TestStubRefreshTokenRepository) reconstruct(token domain.RefreshToken) domain.RefreshToken {
+	var usedAt, revokedAt time.Time
+	if s.used[token.GetTokenHash()] {
+		usedAt = time.Now()
+	}
+	if s.revoked[token.GetTokenHash()] {
+		revokedAt = time.Now()
+	}
+	return domain.ReconstructRefreshToken(
+		token.GetUserID(),
+		token.GetFamilyID(),
+		token.GetTokenHash(),
+		token.GetDevice(),
+		token.GetAuthTime(),
+		token.GetCreatedAt(),
+		token.GetExpiresAt(),
+		usedAt,
+		revokedAt,
+	)
+}
+
+// TestStubAccessTokenSigner signs as "<user id>:<unix expiry>".
+type TestStubAccessTokenSigner struct{}
+
+func (s *TestStubAccessTokenSigner) Sign(claims usecase.AccessTokenClaims) (string, error) {
+	return fmt.Sprintf("%d:%d", claims.UserID, claims.ExpiresAt.Unix()), nil
+}
+
+func (s *TestStubAccessTokenSigner) Verify(token string) (*usecase.AccessTokenClaims, error) {
+	userID, expiresAt, ok := strings.Cut(token, ":")
+	if !ok {
+		return nil, usecase.ErrInvalidAccessToken
+	}
+	id, err := strconv.Atoi(userID)
+	if err != nil {
+		return nil, usecase.ErrInvalidAccessToken
+	}
+	exp, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || exp <= time.Now().Unix() {
+		return nil, usecase.ErrInvalidAccessToken
+	}
+	return &usecase.AccessTokenClaims{UserID: domain.UserID(id), ExpiresAt: time.Unix(exp, 0)}, nil
+}
+
+func newTokenUseCase(t *testing.T, ur *TestStubUserRepository, rtr *TestStubRefreshTokenRepository, sr *TestStubSessionRepository, conf usecase.TokenUseCaseConfig) *usecase.TokenUseCase {
+	au, err := usecase.NewAuthUseCase(ur, &TestStubPasswordHasher{}, &TestStubLoginAttemptRepository{}, &TestStubAuditLogRepository{}, usecase.AuthUseCaseConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return usecase.NewTokenUseCase(au, ur, rtr, usecase.SessionRepositories{sr, rtr}, &TestStubAccessTokenSigner{}, conf)
+}
+
+func TestIssueTokenUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	conf := usecase.TokenUseCaseConfig{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour}
+
+	passwordGrant := usecase.IssueTokenUseCaseInput{
+		GrantType: usecase.GrantTypePassword,
+		Name:      "test01",
+		Password:  "test01",
+		Device:    "app/1.0",
+	}
+
+	t.Run("Password grant", func(t *testing.T) {
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubRefreshTokenRepository{}, &TestStubSessionRepository{}, conf)
+
+		output, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 15*time.Minute, output.ExpiresIn)
+		assert.NotEmpty(t, output.RefreshToken)
+
+		verified, err := tu.VerifyAccessToken(context.Background(), usecase.VerifyAccessTokenUseCaseInput{AccessToken: output.AccessToken})
+		if assert.NoError(t, err) {
+			assert.Equal(t, 1, verified.UserID)
+		}
+	})
+
+	cases := []struct {
+		name    string
+		input   usecase.IssueTokenUseCaseInput
+		wantErr error
+	}{
+		{
+			name:    "Wrong password",
+			input:   usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypePassword, Name: "test01", Password: "wrong"},
+			wantErr: usecase.ErrLoginFailed,
+		},
+		{
+			name:    "Unknown refresh token",
+			input:   usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: "unknown"},
+			wantErr: usecase.ErrInvalidToken,
+		},
+		{
+			name:    "Unsupported grant type",
+			input:   usecase.IssueTokenUseCaseInput{GrantType: "client_credentials"},
+			wantErr: usecase.ErrUnsupportedGrantType,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			rtr := &TestStubRefreshTokenRepository{}
+			sr := &TestStubSessionRepository{}
+			tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, rtr, sr, conf)
+
+			_, err := tu.IssueToken(context.Background(), tt.input)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Empty(t, rtr.tokenStore)
+			assert.Empty(t, sr.revoked)
+		})
+	}
+
+	t.Run("Refresh token is rotated", func(t *testing.T) {
+		rtr := &TestStubRefreshTokenRepository{}
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, rtr, &TestStubSessionRepository{}, conf)
+		first, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		refreshGrant := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: first.RefreshToken}
+		second, err := tu.IssueToken(context.Background(), refreshGrant)
+		if assert.NoError(t, err) {
+			assert.NotEqual(t, first.RefreshToken, second.RefreshToken)
+		}
+
+		// the replacement belongs to the same session
+		if assert.Len(t, rtr.tokenStore, 2) {
+			assert.Equal(t, rtr.tokenStore[0].GetFamilyID(), rtr.tokenStore[1].GetFamilyID())
+			assert.Equal(t, "app/1.0", rtr.tokenStore[1].GetDevice())
+			assert.Equal(t, rtr.tokenStore[0].GetAuthTime(), rtr.tokenStore[1].GetAuthTime())
+		}
+	})
+
+	// the rotated token is presented again, e.g. by an attacker who stole it
+	reuseCases := []struct {
+		name string
+		// setup runs between the rotation of the first token and its reuse
+		setup func(t *testing.T, tu *usecase.TokenUseCase, rtr *TestStubRefreshTokenRepository, second *usecase.IssueTokenUseCaseOutput)
+	}{
+		{name: "Reused refresh token revokes all sessions"},
+		{
+			name: "Reused refresh token after it expired",
+			setup: func(_ *testing.T, _ *usecase.TokenUseCase, rtr *TestStubRefreshTokenRepository, _ *usecase.IssueTokenUseCaseOutput) {
+				first := rtr.tokenStore[0]
+				rtr.tokenStore[0] = domain.ReconstructRefreshToken(
+					first.GetUserID(), first.GetFamilyID(), first.GetTokenHash(), first.GetDevice(),
+					first.GetAuthTime(), first.GetCreatedAt(), time.Now().Add(-time.Minute), time.Time{}, time.Time{},
+				)
+			},
+		},
+		{
+			name: "Reused refresh token after its session was logged out",
+			setup: func(t *testing.T, tu *usecase.TokenUseCase, _ *TestStubRefreshTokenRepository, second *usecase.IssueTokenUseCaseOutput) {
+				err := tu.RevokeToken(context.Background(), usecase.RevokeTokenUseCaseInput{RefreshToken: second.RefreshToken})
+				assert.NoError(t, err)
+			},
+		},
+	}
+
+	for _, tt := range reuseCases {
+		t.Run(tt.name, func(t *testing.T) {
+			rtr := &TestStubRefreshTokenRepository{}
+			sr := &TestStubSessionRepository{}
+			tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, rtr, sr, conf)
+			first, err := tu.IssueToken(context.Background(), passwordGrant)
+			if !assert.NoError(t, err) {
+				return
+			}
+			refreshGrant := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: first.RefreshToken}
+			second, err := tu.IssueToken(context.Background(), refreshGrant)
+			if !assert.NoError(t, err) {
+				return
+			}
+			other, err := tu.IssueToken(context.Background(), passwordGrant)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if tt.setup != nil {
+				tt.setup(t, tu, rtr, second)
+			}
+
+			_, err = tu.IssueToken(context.Background(), refreshGrant)
+			assert.Equal(t, usecase.ErrInvalidToken, err)
+			assert.Equal(t, []domain.UserID{1}, sr.revoked)
+
+			// neither the legitimate replacement nor the other device can refresh any more
+			for _, refreshToken := range []string{second.RefreshToken, other.RefreshToken} {
+				input := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: refreshToken}
+				_, err = tu.IssueToken(context.Background(), input)
+				assert.Equal(t, usecase.ErrInvalidToken, err)
+			}
+		})
+	}
+
+	t.Run("Replaying a reused token logs out again", func(t *testing.T) {
+		sr := &TestStubSessionRepository{}
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubRefreshTokenRepository{}, sr, conf)
+		stolen, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		refreshGrant := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: stolen.RefreshToken}
+		if _, err := tu.IssueToken(context.Background(), refreshGrant); !assert.NoError(t, err) {
+			return
+		}
+		_, err = tu.IssueToken(context.Background(), refreshGrant)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+		assert.Equal(t, []domain.UserID{1}, sr.revoked)
+
+		// the user logs in again, and the stolen token is replayed from the revoked family
+		fresh, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = tu.IssueToken(context.Background(), refreshGrant)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+		assert.Equal(t, []domain.UserID{1, 1}, sr.revoked)
+
+		input := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: fresh.RefreshToken}
+		_, err = tu.IssueToken(context.Background(), input)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+	})
+
+	t.Run("Concurrent use of a refresh token does not log out", func(t *testing.T) {
+		rtr := &TestStubRefreshTokenRepository{}
+		sr := &TestStubSessionRepository{}
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, rtr, sr, conf)
+		output, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		rtr.raced = true
+
+		input := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: output.RefreshToken}
+		_, err = tu.IssueToken(context.Background(), input)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+		assert.Empty(t, sr.revoked)
+		assert.Empty(t, rtr.revoked)
+	})
+
+	t.Run("Refresh token of disabled user", func(t *testing.T) {
+		ur := &TestStubUserRepository{userStore: []domain.User{user01}}
+		tu := newTokenUseCase(t, ur, &TestStubRefreshTokenRepository{}, &TestStubSessionRepository{}, conf)
+		output, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		ur.userStore[0].SetDisabledAt(time.Now())
+
+		input := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: output.RefreshToken}
+		_, err = tu.IssueToken(context.Background(), input)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+	})
+
+	t.Run("Revoked refresh token", func(t *testing.T) {
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubRefreshTokenRepository{}, &TestStubSessionRepository{}, conf)
+		output, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+		err = tu.RevokeToken(context.Background(), usecase.RevokeTokenUseCaseInput{RefreshToken: output.RefreshToken})
+		assert.NoError(t, err)
+
+		input := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypeRefreshToken, RefreshToken: output.RefreshToken}
+		_, err = tu.IssueToken(context.Background(), input)
+		assert.Equal(t, usecase.ErrInvalidToken, err)
+	})
+
+	t.Run("Expired access token", func(t *testing.T) {
+		expiredConf := conf
+		expiredConf.AccessTokenTTL = -time.Minute
+		tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubRefreshTokenRepository{}, &TestStubSessionRepository{}, expiredConf)
+		output, err := tu.IssueToken(context.Background(), passwordGrant)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = tu.VerifyAccessToken(context.Background(), usecase.VerifyAccessTokenUseCaseInput{AccessToken: output.AccessToken})
+		assert.ErrorIs(t, err, usecase.ErrInvalidAccessToken)
+	})
+}
+
+func TestSessionsUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	conf := usecase.TokenUseCaseConfig{AccessTokenTTL: 15 * time.Minute, RefreshTokenTTL: time.Hour}
+	passwordGrant := usecase.IssueTokenUseCaseInput{GrantType: usecase.GrantTypePassword, Name: "test01", Password: "test01"}
+
+	tu := newTokenUseCase(t, &TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubRefreshTokenRepository{}, &TestStubSessionRepository{}, conf)
+	for _, device := range []string{"app/1.0", "cli/2.0"} {
+		input := passwordGrant
+		input.Device = device
+		if _, err := tu.IssueToken(context.Background(), input); !assert.NoError(t, err) {
+			return
+		}
+	}
+
+	output, err := tu.GetSessions(context.Background(), usecase.GetSessionsUseCaseInput{UserID: 1})
+	if !assert.NoError(t, err) || !assert.Len(t, output.Sessions, 2) {
+		return
+	}
+	assert.Equal(t, "cli/2.0", output.Sessions[0].Device)
+	assert.Equal(t, "app/1.0", output.Sessions[1].Device)
+
+	t.Run("Sessions of another user", func(t *testing.T) {
+		others, err := tu.GetSessions(context.Background(), usecase.GetSessionsUseCaseInput{UserID: 2})
+		if assert.NoError(t, err) {
+			assert.Empty(t, others.Sessions)
+		}
+
+		input := usecase.RevokeSessionUseCaseInput{UserID: 2, SessionID: output.Sessions[0].ID}
+		assert.Equal(t, usecase.ErrSessionNotFound, tu.RevokeSession(context.Background(), input))
+	})
+
+	t.Run("Revoke session", func(t *testing.T) {
+		input := usecase.RevokeSessionUseCaseInput{UserID: 1, SessionID: output.Sessions[0].ID}
+		assert.NoError(t, tu.RevokeSession(context.Background(), input))
+
+		remaining, err := tu.GetSessions(context.Background(), usecase.GetSessionsUseCaseInput{UserID: 1})
+		if assert.NoError(t, err) {
+			assert.Equal(t, []usecase.Session{output.Sessions[1]}, remaining.Sessions)
+		}
+
+		// a revoked session cannot be revoked again
+		assert.Equal(t, usecase.ErrSessionNotFound, tu.RevokeSession(context.Background(), input))
+	})
+}
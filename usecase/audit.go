@@ -0,0 +1,17 @@
+package usecase
+
+import "context"
+
+// AuditEntry records a security relevant event.
+type AuditEntry struct {
+	Action string
+	// Subject is what the action concerns, e.g. "user:alice"
+	Subject string
+	// IP is the client IP of the request that caused the event, if any
+	IP     string
+	Detail string
+}
+
+type IAuditLogRepository interface {
+	Create(ctx context.Context, entry AuditEntry) error
+}
@@ -1,21 +1,154 @@
 package usecase
 
-import "errors"
+import (
+	"context"
+	"errors"
+	"time"
 
-var ErrLoginFailed = errors.New("failed login")
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+var (
+	ErrLoginFailed  = errors.New("failed login")
+	ErrUserDisabled = errors.New("user is disabled")
+)
+
+// dummyPassword is hashed once and verified against when the user does not exist,
+// so that unknown and known names take the same time.
+const dummyPassword = "dummy-password-for-unknown-user"
 
 type LoginUseCaseInput struct {
 	Name     string
 	Password string
+	// IP is the client IP, which failed logins are counted by as well
+	IP string
+}
+
+type GetLoginUserUseCaseInput struct {
+	ID int
 }
 
-type AuthUseCase struct{}
+type GetLoginUserUseCaseOutput struct {
+	ID   int
+	Name string
+}
 
-func NewAuthUseCase() *AuthUseCase {
-	return &AuthUseCase{}
+type AuthUseCaseConfig struct {
+	// RequireVerifiedEmail rejects the login of users who have not verified their email
+	RequireVerifiedEmail bool
+	Throttle             LoginThrottleConfig
 }
 
-func (au *AuthUseCase) Login(input LoginUseCaseInput) error {
-	// Todo: Implement
-	return nil
+type AuthUseCase struct {
+	ur        IUserRepository
+	ph        PasswordHasher
+	dummyHash string
+	throttle  loginThrottle
+	conf      AuthUseCaseConfig
+}
+
+func NewAuthUseCase(
+	ur IUserRepository,
+	ph PasswordHasher,
+	lar ILoginAttemptRepository,
+	alr IAuditLogRepository,
+	conf AuthUseCaseConfig,
+) (*AuthUseCase, error) {
+	dummyHash, err := ph.Hash(dummyPassword)
+	if err != nil {
+		return nil, err
+	}
+	return &AuthUseCase{
+		ur:        ur,
+		ph:        ph,
+		dummyHash: dummyHash,
+		throttle:  loginThrottle{lar: lar, alr: alr, conf: conf.Throttle},
+		conf:      conf,
+	}, nil
+}
+
+func (au *AuthUseCase) Login(ctx context.Context, input LoginUseCaseInput) (domain.UserID, error) {
+	// reject the attempt while the name or the IP is locked out or delayed,
+	// and count it before verifying the password, so that concurrent guesses cannot all pass
+	now := time.Now()
+	keys := au.throttle.keys(input.Name, input.IP)
+	counted, err := au.throttle.begin(ctx, keys, now)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, err := au.login(ctx, input)
+	switch {
+	case errors.Is(err, ErrLoginFailed):
+		if err := au.throttle.fail(ctx, keys, counted, input.IP, now); err != nil {
+			return 0, err
+		}
+		return 0, ErrLoginFailed
+	case err != nil && !errors.Is(err, ErrUserDisabled) && !errors.Is(err, ErrEmailNotVerified):
+		return 0, err
+	}
+
+	// the password was right, so the attempt is not a failure
+	if err := au.throttle.succeed(ctx, input.Name, input.IP); err != nil {
+		return 0, err
+	}
+	return userID, err
+}
+
+func (au *AuthUseCase) login(ctx context.Context, input LoginUseCaseInput) (domain.UserID, error) {
+	// get user by name
+	user, err := au.ur.GetUserByName(ctx, input.Name)
+	if err != nil {
+		return 0, err
+	}
+
+	// verify password even if user does not exist to avoid user enumeration by timing
+	if user == nil {
+		_, _, _ = au.ph.Verify(input.Password, au.dummyHash)
+		return 0, ErrLoginFailed
+	}
+	matched, needsRehash, err := au.ph.Verify(input.Password, user.GetPassword())
+	if err != nil {
+		return 0, err
+	}
+	if !matched {
+		return 0, ErrLoginFailed
+	}
+
+	// checked after the password, so that it does not reveal whether the user exists
+	if user.IsDisabled() {
+		return 0, ErrUserDisabled
+	}
+	if au.conf.RequireVerifiedEmail && !user.IsEmailVerified() {
+		return 0, ErrEmailNotVerified
+	}
+
+	// upgrade the stored hash when hashing parameters have changed.
+	// the login has already succeeded, so a failure here is retried on the next login.
+	if needsRehash {
+		if hashedPassword, err := au.ph.Hash(input.Password); err == nil {
+			_ = au.ur.UpdatePassword(ctx, user.GetID(), hashedPassword)
+		}
+	}
+
+	return user.GetID(), nil
+}
+
+func (au *AuthUseCase) GetLoginUser(ctx context.Context, input GetLoginUserUseCaseInput) (*GetLoginUserUseCaseOutput, error) {
+	// get user by UserID
+	user, err := au.ur.GetUserByID(ctx, domain.UserID(input.ID))
+	if err != nil {
+		return nil, err
+	}
+
+	// check if user exists (a disabled user is logged out of every session)
+	if user == nil || user.IsDisabled() {
+		return nil, ErrUserNotFound
+	}
+
+	output := &GetLoginUserUseCaseOutput{
+		ID:   user.GetID().Int(),
+		Name: user.GetName(),
+	}
+	return output, nil
 }
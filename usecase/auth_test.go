@@ -0,0 +1,463 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubLoginAttemptRepository struct {
+	attempts map[string]usecase.LoginAttempts
+}
+
+func (s *TestStubLoginAttemptRepository) Get(_ context.Context, key string) (usecase.LoginAttempts, error) {
+	return s.attempts[key], nil
+}
+
+func (s *TestStubLoginAttemptRepository) RecordFailure(
+	_ context.Context,
+	key string,
+	now time.Time,
+	window time.Duration,
+) (usecase.LoginAttempts, error) {
+	if s.attempts == nil {
+		s.attempts = map[string]usecase.LoginAttempts{}
+	}
+	attempts := s.attempts[key]
+	if now.Sub(attempts.LastFailureAt) > window {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailureAt = now
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *TestStubLoginAttemptRepository) Release(_ context.Context, key string) error {
+	if attempts, ok := s.attempts[key]; ok && attempts.Failures > 0 {
+		attempts.Failures--
+		s.attempts[key] = attempts
+	}
+	return nil
+}
+
+func (s *TestStubLoginAttemptRepository) Lock(_ context.Context, key string, until time.Time) error {
+	if s.attempts == nil {
+		s.attempts = map[string]usecase.LoginAttempts{}
+	}
+	s.attempts[key] = usecase.LoginAttempts{LockedUntil: until}
+	return nil
+}
+
+func (s *TestStubLoginAttemptRepository) Reset(_ context.Context, key string) error {
+	delete(s.attempts, key)
+	return nil
+}
+
+// TestStubRacingLoginAttemptRepository counts another attempt right after each Get,
+// as a concurrent login would between the check and the count.
+type TestStubRacingLoginAttemptRepository struct {
+	*TestStubLoginAttemptRepository
+}
+
+func (s TestStubRacingLoginAttemptRepository) Get(ctx context.Context, key string) (usecase.LoginAttempts, error) {
+	attempts, err := s.TestStubLoginAttemptRepository.Get(ctx, key)
+	if err != nil {
+		return attempts, err
+	}
+	_, err = s.RecordFailure(ctx, key, time.Now(), time.Hour)
+	return attempts, err
+}
+
+type TestStubAuditLogRepository struct {
+	entries []usecase.AuditEntry
+}
+
+func (s *TestStubAuditLogRepository) Create(_ context.Context, entry usecase.AuditEntry) error {
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func TestNewAuthUseCase(t *testing.T) {
+	t.Run("Hash failure", func(t *testing.T) {
+		hashErr := errors.New("hash failed")
+		_, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{},
+			&TestStubPasswordHasher{err: hashErr},
+			&TestStubLoginAttemptRepository{},
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{},
+		)
+		assert.ErrorIs(t, err, hashErr)
+	})
+}
+
+func TestLoginUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(
+		1,
+		"test01",
+		"hashed:test01",
+		"test01@test.com",
+		time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+	)
+	au, err := usecase.NewAuthUseCase(
+		&TestStubUserRepository{userStore: []domain.User{user01}},
+		&TestStubPasswordHasher{},
+		&TestStubLoginAttemptRepository{},
+		&TestStubAuditLogRepository{},
+		usecase.AuthUseCaseConfig{},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Success Login", func(t *testing.T) {
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01"}
+		got, err := au.Login(context.Background(), input)
+		assert.NoError(t, err)
+		assert.Equal(t, domain.UserID(1), got)
+	})
+
+	t.Run("Login failed", func(t *testing.T) {
+		cases := []struct {
+			name  string
+			input usecase.LoginUseCaseInput
+		}{
+			{
+				name:  "wrong password",
+				input: usecase.LoginUseCaseInput{Name: "test01", Password: "wrong"},
+			},
+			{
+				name:  "unknown user",
+				input: usecase.LoginUseCaseInput{Name: "unknown", Password: "test01"},
+			},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				_, err := au.Login(context.Background(), tt.input)
+				assert.Equal(t, usecase.ErrLoginFailed, err)
+			})
+		}
+	})
+
+	t.Run("Rehash outdated password", func(t *testing.T) {
+		user02 := domain.ReconstructUser(
+			2,
+			"test02",
+			"outdated:test02",
+			"test02@test.com",
+			time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+		ur := &TestStubUserRepository{userStore: []domain.User{user02}}
+		au, err := usecase.NewAuthUseCase(ur, &TestStubPasswordHasher{}, &TestStubLoginAttemptRepository{}, &TestStubAuditLogRepository{}, usecase.AuthUseCaseConfig{})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.LoginUseCaseInput{Name: "test02", Password: "test02"}
+		if _, err := au.Login(context.Background(), input); assert.NoError(t, err) {
+			assert.Equal(t, "hashed:test02", ur.userStore[0].GetPassword())
+		}
+	})
+
+	t.Run("Email not verified", func(t *testing.T) {
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{user01}},
+			&TestStubPasswordHasher{},
+			&TestStubLoginAttemptRepository{},
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{RequireVerifiedEmail: true},
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01"}
+		_, err = au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrEmailNotVerified)
+
+		input.Password = "wrong"
+		_, err = au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrLoginFailed)
+	})
+
+	t.Run("User disabled", func(t *testing.T) {
+		disabled := user01
+		disabled.SetDisabledAt(time.Now())
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{disabled}},
+			&TestStubPasswordHasher{},
+			&TestStubLoginAttemptRepository{},
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{},
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01"}
+		_, err = au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrUserDisabled)
+
+		input.Password = "wrong"
+		_, err = au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrLoginFailed)
+	})
+}
+
+func TestLoginThrottle(t *testing.T) {
+	user01 := domain.ReconstructUser(
+		1,
+		"test01",
+		"hashed:test01",
+		"test01@test.com",
+		time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+	)
+	newAuthUseCase := func(t *testing.T, throttle usecase.LoginThrottleConfig) (*usecase.AuthUseCase, *TestStubLoginAttemptRepository, *TestStubAuditLogRepository) {
+		lar := &TestStubLoginAttemptRepository{}
+		alr := &TestStubAuditLogRepository{}
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{user01}},
+			&TestStubPasswordHasher{},
+			lar,
+			alr,
+			usecase.AuthUseCaseConfig{Throttle: throttle},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return au, lar, alr
+	}
+
+	t.Run("lock out the user name", func(t *testing.T) {
+		au, _, alr := newAuthUseCase(t, usecase.LoginThrottleConfig{
+			MaxFailuresPerUser: 3,
+			FailureWindow:      time.Hour,
+			LockoutDuration:    time.Hour,
+		})
+
+		// the name is compared case-insensitively, and the failures from any IP count
+		for i, ip := range []string{"192.0.2.1", "192.0.2.2", "192.0.2.3"} {
+			input := usecase.LoginUseCaseInput{Name: "TEST01", Password: "wrong", IP: ip}
+			_, err := au.Login(context.Background(), input)
+			assert.ErrorIs(t, err, usecase.ErrLoginFailed, i)
+		}
+
+		// even the right password is rejected while locked
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01", IP: "192.0.2.4"}
+		_, err := au.Login(context.Background(), input)
+		var throttled *usecase.LoginThrottledError
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.ErrorIs(t, err, usecase.ErrLoginLocked)
+			assert.True(t, throttled.Locked)
+			assert.InDelta(t, time.Hour, throttled.RetryAfter(), float64(time.Minute))
+		}
+
+		if assert.Len(t, alr.entries, 1) {
+			assert.Equal(t, usecase.AuditActionLoginLocked, alr.entries[0].Action)
+			assert.Equal(t, "user:test01", alr.entries[0].Subject)
+			assert.Equal(t, "192.0.2.3", alr.entries[0].IP)
+		}
+	})
+
+	t.Run("lock out the IP", func(t *testing.T) {
+		au, _, alr := newAuthUseCase(t, usecase.LoginThrottleConfig{
+			MaxFailuresPerIP: 2,
+			FailureWindow:    time.Hour,
+			LockoutDuration:  time.Hour,
+		})
+
+		for _, name := range []string{"unknown01", "unknown02"} {
+			input := usecase.LoginUseCaseInput{Name: name, Password: "wrong", IP: "192.0.2.1"}
+			_, err := au.Login(context.Background(), input)
+			assert.ErrorIs(t, err, usecase.ErrLoginFailed)
+		}
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01", IP: "192.0.2.1"}
+		_, err := au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrLoginLocked)
+
+		// other IPs are not affected
+		input.IP = "192.0.2.2"
+		_, err = au.Login(context.Background(), input)
+		assert.NoError(t, err)
+
+		if assert.Len(t, alr.entries, 1) {
+			assert.Equal(t, "ip:192.0.2.1", alr.entries[0].Subject)
+		}
+	})
+
+	t.Run("delay after a failure", func(t *testing.T) {
+		au, lar, _ := newAuthUseCase(t, usecase.LoginThrottleConfig{
+			FailureWindow: time.Hour,
+			BaseDelay:     time.Minute,
+			MaxDelay:      3 * time.Minute,
+		})
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "wrong", IP: "192.0.2.1"}
+		_, err := au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrLoginFailed)
+
+		input.Password = "test01"
+		_, err = au.Login(context.Background(), input)
+		var throttled *usecase.LoginThrottledError
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.ErrorIs(t, err, usecase.ErrTooManyRequests)
+			assert.False(t, throttled.Locked)
+			assert.InDelta(t, time.Minute, throttled.RetryAfter(), float64(time.Second))
+		}
+
+		// the delay doubles with every failure, up to the maximum
+		lar.attempts["user:test01"] = usecase.LoginAttempts{Failures: 3, LastFailureAt: time.Now()}
+		_, err = au.Login(context.Background(), input)
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.InDelta(t, 3*time.Minute, throttled.RetryAfter(), float64(time.Second))
+		}
+
+		// the delay has passed
+		lar.attempts = map[string]usecase.LoginAttempts{
+			"user:test01":  {Failures: 1, LastFailureAt: time.Now().Add(-2 * time.Minute)},
+			"ip:192.0.2.1": {Failures: 1, LastFailureAt: time.Now().Add(-2 * time.Minute)},
+		}
+		_, err = au.Login(context.Background(), input)
+		assert.NoError(t, err)
+	})
+
+	t.Run("success resets the user name but not the IP", func(t *testing.T) {
+		au, lar, _ := newAuthUseCase(t, usecase.LoginThrottleConfig{
+			MaxFailuresPerUser: 3,
+			MaxFailuresPerIP:   3,
+			FailureWindow:      time.Hour,
+			LockoutDuration:    time.Hour,
+		})
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "wrong", IP: "192.0.2.1"}
+		_, err := au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrLoginFailed)
+
+		input.Password = "test01"
+		_, err = au.Login(context.Background(), input)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0, lar.attempts["user:test01"].Failures)
+		assert.Equal(t, 1, lar.attempts["ip:192.0.2.1"].Failures)
+	})
+
+	t.Run("concurrent attempts wait for the first one", func(t *testing.T) {
+		lar := &TestStubLoginAttemptRepository{}
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{user01}},
+			&TestStubPasswordHasher{},
+			TestStubRacingLoginAttemptRepository{lar},
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{Throttle: usecase.LoginThrottleConfig{
+				MaxFailuresPerUser: 3,
+				FailureWindow:      time.Hour,
+				LockoutDuration:    time.Hour,
+				BaseDelay:          time.Minute,
+				MaxDelay:           time.Minute,
+			}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// even the right password is not verified, and the attempt stays counted
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01", IP: "192.0.2.1"}
+		_, err = au.Login(context.Background(), input)
+		var throttled *usecase.LoginThrottledError
+		if assert.ErrorAs(t, err, &throttled) {
+			assert.ErrorIs(t, err, usecase.ErrTooManyRequests)
+			assert.Equal(t, time.Minute, throttled.RetryAfter())
+		}
+		assert.Equal(t, 2, lar.attempts["user:test01"].Failures)
+	})
+
+	t.Run("the right password of a disabled user is not a failure", func(t *testing.T) {
+		disabled := domain.ReconstructUser(
+			1,
+			"test01",
+			"hashed:test01",
+			"test01@test.com",
+			time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+		disabled.SetDisabledAt(time.Now())
+		lar := &TestStubLoginAttemptRepository{}
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{disabled}},
+			&TestStubPasswordHasher{},
+			lar,
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{Throttle: usecase.LoginThrottleConfig{MaxFailuresPerIP: 3, FailureWindow: time.Hour}},
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := usecase.LoginUseCaseInput{Name: "test01", Password: "test01", IP: "192.0.2.1"}
+		_, err = au.Login(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrUserDisabled)
+		assert.Equal(t, 0, lar.attempts["user:test01"].Failures)
+		assert.Equal(t, 0, lar.attempts["ip:192.0.2.1"].Failures)
+	})
+}
+
+func TestGetLoginUserUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(
+		1,
+		"test01",
+		"hashed:test01",
+		"test01@test.com",
+		time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+	)
+	au, err := usecase.NewAuthUseCase(
+		&TestStubUserRepository{userStore: []domain.User{user01}},
+		&TestStubPasswordHasher{},
+		&TestStubLoginAttemptRepository{},
+		&TestStubAuditLogRepository{},
+		usecase.AuthUseCaseConfig{},
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	t.Run("Success GetLoginUser", func(t *testing.T) {
+		got, err := au.GetLoginUser(context.Background(), usecase.GetLoginUserUseCaseInput{ID: 1})
+		assert.NoError(t, err)
+		assert.Equal(t, &usecase.GetLoginUserUseCaseOutput{ID: 1, Name: "test01"}, got)
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		_, err := au.GetLoginUser(context.Background(), usecase.GetLoginUserUseCaseInput{ID: 2})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+	})
+
+	t.Run("user disabled", func(t *testing.T) {
+		disabled := user01
+		disabled.SetDisabledAt(time.Now())
+		au, err := usecase.NewAuthUseCase(
+			&TestStubUserRepository{userStore: []domain.User{disabled}},
+			&TestStubPasswordHasher{},
+			&TestStubLoginAttemptRepository{},
+			&TestStubAuditLogRepository{},
+			usecase.AuthUseCaseConfig{},
+		)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		_, err = au.GetLoginUser(context.Background(), usecase.GetLoginUserUseCaseInput{ID: 1})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+	})
+}
@@ -0,0 +1,113 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+var (
+	ErrEmailNotVerified = errors.New("email not verified")
+	ErrTooManyRequests  = errors.New("too many requests")
+)
+
+type VerifyEmailUseCaseInput struct {
+	Token string
+}
+
+type ResendVerificationEmailUseCaseInput struct {
+	Email string
+}
+
+// VerifyEmail marks the email of the token's user as verified.
+func (uc *UserUseCase) VerifyEmail(ctx context.Context, input VerifyEmailUseCaseInput) error {
+	// consume token
+	userID, err := uc.tr.Consume(ctx, domain.UserTokenPurposeEmailVerification, hashToken(input.Token))
+	if err != nil {
+		return err
+	}
+	if userID == nil {
+		return ErrInvalidToken
+	}
+
+	// get user by UserID
+	user, err := uc.ur.GetUserByID(ctx, *userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrInvalidToken
+	}
+
+	// update user
+	if user.IsEmailVerified() {
+		return nil
+	}
+	user.SetEmailVerifiedAt(time.Now())
+	return uc.ur.Update(ctx, *user)
+}
+
+// ResendVerificationEmail mails a new verification token to an unverified user.
+// It succeeds without sending anything for unknown or verified emails, and within the resend interval,
+// so that registered emails cannot be enumerated.
+func (uc *UserUseCase) ResendVerificationEmail(ctx context.Context, input ResendVerificationEmailUseCaseInput) error {
+	// get user by email (an invalid address cannot belong to a user)
+	email, err := domain.NewEmail(input.Email)
+	if err != nil {
+		return nil
+	}
+	user, err := uc.ur.GetUserByEmail(ctx, email.String())
+	if err != nil {
+		return err
+	}
+	if user == nil || user.IsEmailVerified() {
+		return nil
+	}
+
+	// rate limit
+	latest, err := uc.tr.LatestCreatedAt(ctx, user.GetID(), domain.UserTokenPurposeEmailVerification)
+	if err != nil {
+		return err
+	}
+	if time.Since(latest) < uc.conf.VerifyEmailResendInterval {
+		return nil
+	}
+
+	uc.trySendVerificationMail(ctx, *user)
+	return nil
+}
+
+// trySendVerificationMail sends the verification mail for a change that has already been saved,
+// or to a user who asked for another one. A failure is logged instead of being returned,
+// so that the caller cannot tell a registered email from an unknown one.
+func (uc *UserUseCase) trySendVerificationMail(ctx context.Context, user domain.User) {
+	if err := uc.sendVerificationMail(ctx, user); err != nil {
+		log.Printf("failed to send the verification mail to user %d: %v", user.GetID(), err)
+	}
+}
+
+func (uc *UserUseCase) sendVerificationMail(ctx context.Context, user domain.User) error {
+	// issue token
+	token, err := issueToken(ctx, uc.tr, user.GetID(), domain.UserTokenPurposeEmailVerification, uc.conf.VerifyEmailTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	// send mail
+	mail := Mail{
+		To:      user.GetEmail(),
+		Subject: "Verify your email",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen the following link to verify your email. The link expires in %s.\n\n%s?token=%s\n\nIf you did not sign up, you can ignore this mail.\n",
+			user.GetName(),
+			uc.conf.VerifyEmailTokenTTL,
+			uc.conf.VerifyEmailURL,
+			token,
+		),
+	}
+	return uc.m.Send(ctx, mail)
+}
@@ -0,0 +1,152 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyEmailUseCase(t *testing.T) {
+	conf := usecase.UserUseCaseConfig{
+		VerifyEmailURL:            "http://localhost/verify-email",
+		VerifyEmailTokenTTL:       time.Hour,
+		VerifyEmailResendInterval: time.Minute,
+	}
+	signUpInput := usecase.SignUpUseCaseInput{
+		Name:     "test01",
+		Password: "password01",
+		Email:    "test01@test.com",
+		BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Success VerifyEmail", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		m := &TestStubMailer{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		if _, err := uuc.SignUp(context.Background(), signUpInput); !assert.NoError(t, err) || !assert.Len(t, m.sent, 1) {
+			return
+		}
+		assert.Equal(t, "test01@test.com", m.sent[0].To)
+		assert.False(t, ur.userStore[0].IsEmailVerified())
+
+		input := usecase.VerifyEmailUseCaseInput{Token: tokenFromMail(t, m.sent[0])}
+		err := uuc.VerifyEmail(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.True(t, ur.userStore[0].IsEmailVerified())
+		}
+
+		// the token is single-use
+		err = uuc.VerifyEmail(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, &TestStubMailer{}, conf)
+
+		if _, err := uuc.SignUp(context.Background(), signUpInput); !assert.NoError(t, err) {
+			return
+		}
+
+		err := uuc.VerifyEmail(context.Background(), usecase.VerifyEmailUseCaseInput{Token: "invalid"})
+		assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+		assert.False(t, ur.userStore[0].IsEmailVerified())
+	})
+
+	t.Run("Changed email has to be verified again", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		m := &TestStubMailer{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		if _, err := uuc.SignUp(context.Background(), signUpInput); !assert.NoError(t, err) {
+			return
+		}
+		ur.userStore[0].SetEmailVerifiedAt(time.Now())
+
+		email := "updated01@test.com"
+		input := usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Email: &email}
+		if _, err := uuc.UpdateUser(context.Background(), input); !assert.NoError(t, err) {
+			return
+		}
+		assert.False(t, ur.userStore[0].IsEmailVerified())
+		if assert.Len(t, m.sent, 2) {
+			assert.Equal(t, email, m.sent[1].To)
+		}
+	})
+}
+
+func TestResendVerificationEmailUseCase(t *testing.T) {
+	conf := usecase.UserUseCaseConfig{
+		VerifyEmailURL:      "http://localhost/verify-email",
+		VerifyEmailTokenTTL: time.Hour,
+	}
+	signUpInput := usecase.SignUpUseCaseInput{
+		Name:     "test01",
+		Password: "password01",
+		Email:    "test01@test.com",
+		BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	t.Run("Success ResendVerificationEmail", func(t *testing.T) {
+		m := &TestStubMailer{}
+		uuc := usecase.NewUserUseCase(&TestStubUserRepository{}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		if _, err := uuc.SignUp(context.Background(), signUpInput); !assert.NoError(t, err) {
+			return
+		}
+		input := usecase.ResendVerificationEmailUseCaseInput{Email: "test01@test.com"}
+		if err := uuc.ResendVerificationEmail(context.Background(), input); !assert.NoError(t, err) {
+			return
+		}
+		if !assert.Len(t, m.sent, 2) {
+			return
+		}
+
+		// only the latest token is valid
+		err := uuc.VerifyEmail(context.Background(), usecase.VerifyEmailUseCaseInput{Token: tokenFromMail(t, m.sent[0])})
+		assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+		err = uuc.VerifyEmail(context.Background(), usecase.VerifyEmailUseCaseInput{Token: tokenFromMail(t, m.sent[1])})
+		assert.NoError(t, err)
+	})
+
+	// the response is the same whether a mail is sent or not, so that registered emails cannot be enumerated
+	cases := []struct {
+		name           string
+		email          string
+		resendInterval time.Duration
+		verified       bool
+		mailErr        error
+	}{
+		{name: "Too many requests", email: "test01@test.com", resendInterval: time.Minute},
+		{name: "Unknown email", email: "unknown@test.com"},
+		{name: "Verified email", email: "test01@test.com", verified: true},
+		{name: "Mail failure", email: "test01@test.com", mailErr: errors.New("mail server down")},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ur := &TestStubUserRepository{}
+			m := &TestStubMailer{}
+			resendConf := conf
+			resendConf.VerifyEmailResendInterval = tt.resendInterval
+			uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, resendConf)
+
+			if _, err := uuc.SignUp(context.Background(), signUpInput); !assert.NoError(t, err) {
+				return
+			}
+			if tt.verified {
+				ur.userStore[0].SetEmailVerifiedAt(time.Now())
+			}
+			m.err = tt.mailErr
+
+			err := uuc.ResendVerificationEmail(context.Background(), usecase.ResendVerificationEmailUseCaseInput{Email: tt.email})
+			assert.NoError(t, err)
+			assert.Len(t, m.sent, 1, "only the mail of the sign-up is sent")
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var ErrLoginLocked = errors.New("too many failed logins")
+
+// AuditActionLoginLocked is recorded when a user name or a client IP is locked out.
+const AuditActionLoginLocked = "login_locked"
+
+// LoginAttempts are the recent failed logins of a user name or a client IP.
+type LoginAttempts struct {
+	Failures      int
+	LastFailureAt time.Time
+	LockedUntil   time.Time
+}
+
+// ILoginAttemptRepository counts failed logins by key, so that several processes can share the counts.
+type ILoginAttemptRepository interface {
+	// Get returns the zero LoginAttempts if nothing is recorded for the key.
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+	// RecordFailure counts a failed login at now and returns the attempts of the key.
+	// The count starts over if the last failure is older than window.
+	RecordFailure(ctx context.Context, key string, now time.Time, window time.Duration) (LoginAttempts, error)
+	// Release takes back one failure counted by RecordFailure, for an attempt that has succeeded.
+	Release(ctx context.Context, key string) error
+	// Lock keeps the key from logging in until the time and clears its failures.
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// LoginThrottleConfig limits the failed logins per user name and per client IP.
+// A zero maximum or delay disables the lockout or the delay.
+type LoginThrottleConfig struct {
+	MaxFailuresPerUser int
+	MaxFailuresPerIP   int
+	// FailureWindow is how long a failure counts towards the lockout
+	FailureWindow   time.Duration
+	LockoutDuration time.Duration
+	// the n-th consecutive failure delays the next attempt by BaseDelay * 2^(n-1), up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+}
+
+// LoginThrottledError is returned while a user name or a client IP has to wait before the next login.
+// The wait is sent to the client as the Retry-After header.
+type LoginThrottledError struct {
+	Wait time.Duration
+	// Locked is true for a lockout, and false for the delay after a failure
+	Locked bool
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%v, retry after %s", e.Unwrap(), e.Wait)
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	if e.Locked {
+		return ErrLoginLocked
+	}
+	return ErrTooManyRequests
+}
+
+func (e *LoginThrottledError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+// loginThrottle keeps track of the failed logins for AuthUseCase.
+type loginThrottle struct {
+	lar  ILoginAttemptRepository
+	alr  IAuditLogRepository
+	conf LoginThrottleConfig
+}
+
+type loginThrottleKey struct {
+	key         string
+	maxFailures int
+}
+
+// keys returns the keys that a login of the name from the IP is counted under.
+// Names are compared case-insensitively like at sign-up, and unknown names are counted as well,
+// so that a lockout does not reveal whether a user exists.
+func (lt *loginThrottle) keys(name, ip string) []loginThrottleKey {
+	keys := []loginThrottleKey{{key: userThrottleKey(name), maxFailures: lt.conf.MaxFailuresPerUser}}
+	if ip != "" {
+		keys = append(keys, loginThrottleKey{key: ipThrottleKey(ip), maxFailures: lt.conf.MaxFailuresPerIP})
+	}
+	return keys
+}
+
+func userThrottleKey(name string) string {
+	return "user:" + strings.ToLower(name)
+}
+
+func ipThrottleKey(ip string) string {
+	return "ip:" + ip
+}
+
+// begin returns a LoginThrottledError if one of the keys is locked or still delayed,
+// and otherwise counts the attempt as a failure under the keys before the password is verified.
+// Each count is a single statement, so of concurrent attempts only the first one goes on;
+// the others are delayed as if it had failed.
+func (lt *loginThrottle) begin(ctx context.Context, keys []loginThrottleKey, now time.Time) ([]LoginAttempts, error) {
+	before := make([]LoginAttempts, len(keys))
+	for i, k := range keys {
+		attempts, err := lt.lar.Get(ctx, k.key)
+		if err != nil {
+			return nil, err
+		}
+		if now.Before(attempts.LockedUntil) {
+			return nil, &LoginThrottledError{Wait: attempts.LockedUntil.Sub(now), Locked: true}
+		}
+		if now.Sub(attempts.LastFailureAt) > lt.conf.FailureWindow {
+			attempts.Failures = 0
+		}
+		if attempts.Failures == 0 {
+			continue
+		}
+		if next := attempts.LastFailureAt.Add(lt.delay(attempts.Failures)); now.Before(next) {
+			return nil, &LoginThrottledError{Wait: next.Sub(now)}
+		}
+		before[i] = attempts
+	}
+
+	counted := make([]LoginAttempts, len(keys))
+	for i, k := range keys {
+		attempts, err := lt.lar.RecordFailure(ctx, k.key, now, lt.conf.FailureWindow)
+		if err != nil {
+			return nil, err
+		}
+		if attempts.Failures > before[i].Failures+1 {
+			return nil, &LoginThrottledError{Wait: lt.delay(before[i].Failures + 1)}
+		}
+		counted[i] = attempts
+	}
+	return counted, nil
+}
+
+// fail locks the keys whose failures counted by begin have reached their maximum.
+func (lt *loginThrottle) fail(ctx context.Context, keys []loginThrottleKey, counted []LoginAttempts, ip string, now time.Time) error {
+	for i, k := range keys {
+		if k.maxFailures <= 0 || counted[i].Failures < k.maxFailures {
+			continue
+		}
+
+		until := now.Add(lt.conf.LockoutDuration)
+		if err := lt.lar.Lock(ctx, k.key, until); err != nil {
+			return err
+		}
+		entry := AuditEntry{
+			Action:  AuditActionLoginLocked,
+			Subject: k.key,
+			IP:      ip,
+			Detail:  fmt.Sprintf("%d failed logins, locked until %s", counted[i].Failures, until.UTC().Format(time.RFC3339)),
+		}
+		if err := lt.alr.Create(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// succeed resets the failures of the name and takes back the attempt counted by begin from the IP.
+// The IP is not reset, so that one valid account does not hide guessing at others.
+func (lt *loginThrottle) succeed(ctx context.Context, name, ip string) error {
+	if err := lt.lar.Reset(ctx, userThrottleKey(name)); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	return lt.lar.Release(ctx, ipThrottleKey(ip))
+}
+
+// delay returns how long to wait after the n-th consecutive failure.
+func (lt *loginThrottle) delay(failures int) time.Duration {
+	delay := lt.conf.BaseDelay
+	for i := 1; i < failures && delay < lt.conf.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, lt.conf.MaxDelay)
+}
@@ -0,0 +1,224 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+var (
+	ErrPasswordMismatch = errors.New("current password is incorrect")
+	ErrInvalidToken     = errors.New("invalid or expired token")
+)
+
+type ChangePasswordUseCaseInput struct {
+	UserID          int
+	CurrentPassword string
+	NewPassword     string
+}
+
+type RequestPasswordResetUseCaseInput struct {
+	Email string
+}
+
+type ConfirmPasswordResetUseCaseInput struct {
+	Token       string
+	NewPassword string
+}
+
+type ResetPasswordUseCaseInput struct {
+	UserID      int
+	NewPassword string
+}
+
+type ISessionRepository interface {
+	RevokeByUserID(ctx context.Context, userID domain.UserID) error
+}
+
+// SessionRepositories revokes the sessions of a user in each of the repositories,
+// e.g. the cookie sessions and the refresh tokens.
+type SessionRepositories []ISessionRepository
+
+func (rs SessionRepositories) RevokeByUserID(ctx context.Context, userID domain.UserID) error {
+	for _, r := range rs {
+		if err := r.RevokeByUserID(ctx, userID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type PasswordUseCaseConfig struct {
+	// ResetURL is the page the reset token is sent to as the "token" query parameter
+	ResetURL      string
+	ResetTokenTTL time.Duration
+	// ResetResendInterval is the minimum interval between reset mails to a user
+	ResetResendInterval time.Duration
+}
+
+type PasswordUseCase struct {
+	ur   IUserRepository
+	tr   IUserTokenRepository
+	sr   ISessionRepository
+	ph   PasswordHasher
+	m    Mailer
+	conf PasswordUseCaseConfig
+}
+
+func NewPasswordUseCase(
+	ur IUserRepository,
+	tr IUserTokenRepository,
+	sr ISessionRepository,
+	ph PasswordHasher,
+	m Mailer,
+	conf PasswordUseCaseConfig,
+) *PasswordUseCase {
+	return &PasswordUseCase{ur: ur, tr: tr, sr: sr, ph: ph, m: m, conf: conf}
+}
+
+func (pu *PasswordUseCase) ChangePassword(ctx context.Context, input ChangePasswordUseCaseInput) error {
+	if err := domain.ValidatePassword(input.NewPassword); err != nil {
+		return err
+	}
+
+	// get user by UserID
+	user, err := pu.ur.GetUserByID(ctx, domain.UserID(input.UserID))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	// verify current password
+	matched, _, err := pu.ph.Verify(input.CurrentPassword, user.GetPassword())
+	if err != nil {
+		return err
+	}
+	if !matched {
+		return ErrPasswordMismatch
+	}
+
+	// update password
+	hashedPassword, err := pu.ph.Hash(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	return pu.ur.UpdatePassword(ctx, user.GetID(), hashedPassword)
+}
+
+// RequestPasswordReset mails a reset token to the user.
+// It succeeds without sending anything for unknown emails and within the resend interval,
+// and even if the mail fails, so that registered emails cannot be enumerated.
+func (pu *PasswordUseCase) RequestPasswordReset(ctx context.Context, input RequestPasswordResetUseCaseInput) error {
+	// get user by email (an invalid address cannot belong to a user)
+	email, err := domain.NewEmail(input.Email)
+	if err != nil {
+		return nil
+	}
+	user, err := pu.ur.GetUserByEmail(ctx, email.String())
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return nil
+	}
+
+	// rate limit
+	latest, err := pu.tr.LatestCreatedAt(ctx, user.GetID(), domain.UserTokenPurposePasswordReset)
+	if err != nil {
+		return err
+	}
+	if time.Since(latest) < pu.conf.ResetResendInterval {
+		return nil
+	}
+
+	if err := pu.sendResetMail(ctx, *user); err != nil {
+		log.Printf("failed to send the password reset mail to user %d: %v", user.GetID(), err)
+	}
+	return nil
+}
+
+func (pu *PasswordUseCase) sendResetMail(ctx context.Context, user domain.User) error {
+	// issue token
+	token, err := issueToken(ctx, pu.tr, user.GetID(), domain.UserTokenPurposePasswordReset, pu.conf.ResetTokenTTL)
+	if err != nil {
+		return err
+	}
+
+	// send mail
+	mail := Mail{
+		To:      user.GetEmail(),
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hello %s,\n\nOpen the following link to reset your password. The link expires in %s.\n\n%s?token=%s\n\nIf you did not request a password reset, you can ignore this mail.\n",
+			user.GetName(),
+			pu.conf.ResetTokenTTL,
+			pu.conf.ResetURL,
+			token,
+		),
+	}
+	return pu.m.Send(ctx, mail)
+}
+
+// ConfirmPasswordReset sets the new password and logs the user out of every session.
+func (pu *PasswordUseCase) ConfirmPasswordReset(ctx context.Context, input ConfirmPasswordResetUseCaseInput) error {
+	// validated before the token is consumed, so that the user can retry with another password
+	if err := domain.ValidatePassword(input.NewPassword); err != nil {
+		return err
+	}
+
+	// consume token
+	userID, err := pu.tr.Consume(ctx, domain.UserTokenPurposePasswordReset, hashToken(input.Token))
+	if err != nil {
+		return err
+	}
+	if userID == nil {
+		return ErrInvalidToken
+	}
+
+	// update password
+	hashedPassword, err := pu.ph.Hash(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := pu.ur.UpdatePassword(ctx, *userID, hashedPassword); err != nil {
+		return err
+	}
+
+	// invalidate all sessions
+	return pu.sr.RevokeByUserID(ctx, *userID)
+}
+
+// ResetPassword sets the new password without the current one, for administrators,
+// and logs the user out of every session.
+func (pu *PasswordUseCase) ResetPassword(ctx context.Context, input ResetPasswordUseCaseInput) error {
+	if err := domain.ValidatePassword(input.NewPassword); err != nil {
+		return err
+	}
+
+	// get user by UserID
+	user, err := pu.ur.GetUserByID(ctx, domain.UserID(input.UserID))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	// update password
+	hashedPassword, err := pu.ph.Hash(input.NewPassword)
+	if err != nil {
+		return err
+	}
+	if err := pu.ur.UpdatePassword(ctx, user.GetID(), hashedPassword); err != nil {
+		return err
+	}
+
+	// invalidate all sessions
+	return pu.sr.RevokeByUserID(ctx, user.GetID())
+}
@@ -0,0 +1,338 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubUserTokenRepository struct {
+	tokenStore []TestStubUserToken
+	usedHashes map[string]bool
+}
+
+type TestStubUserToken struct {
+	token     domain.UserToken
+	createdAt time.Time
+}
+
+func (s *TestStubUserTokenRepository) Create(_ context.Context, token domain.UserToken) error {
+	s.tokenStore = append(s.tokenStore, TestStubUserToken{token: token, createdAt: time.Now()})
+	return nil
+}
+
+func (s *TestStubUserTokenRepository) Consume(
+	_ context.Context,
+	purpose domain.UserTokenPurpose,
+	tokenHash string,
+) (*domain.UserID, error) {
+	for _, stored := range s.tokenStore {
+		token := stored.token
+		if token.GetPurpose() != purpose || token.GetTokenHash() != tokenHash {
+			continue
+		}
+		if s.usedHashes[tokenHash] || !token.GetExpiresAt().After(time.Now()) {
+			return nil, nil
+		}
+		if s.usedHashes == nil {
+			s.usedHashes = map[string]bool{}
+		}
+		s.usedHashes[tokenHash] = true
+		userID := token.GetUserID()
+		return &userID, nil
+	}
+	return nil, nil
+}
+
+func (s *TestStubUserTokenRepository) DeleteByUserID(
+	_ context.Context,
+	userID domain.UserID,
+	purpose domain.UserTokenPurpose,
+) error {
+	remaining := []TestStubUserToken{}
+	for _, stored := range s.tokenStore {
+		if stored.token.GetUserID() != userID || stored.token.GetPurpose() != purpose {
+			remaining = append(remaining, stored)
+		}
+	}
+	s.tokenStore = remaining
+	return nil
+}
+
+func (s *TestStubUserTokenRepository) LatestCreatedAt(
+	_ context.Context,
+	userID domain.UserID,
+	purpose domain.UserTokenPurpose,
+) (time.Time, error) {
+	var latest time.Time
+	for _, stored := range s.tokenStore {
+		if stored.token.GetUserID() == userID && stored.token.GetPurpose() == purpose && stored.createdAt.After(latest) {
+			latest = stored.createdAt
+		}
+	}
+	return latest, nil
+}
+
+type TestStubSessionRepository struct {
+	revoked []domain.UserID
+}
+
+func (s *TestStubSessionRepository) RevokeByUserID(_ context.Context, userID domain.UserID) error {
+	s.revoked = append(s.revoked, userID)
+	return nil
+}
+
+type TestStubMailer struct {
+	sent []usecase.Mail
+	err  error
+}
+
+func (m *TestStubMailer) Send(_ context.Context, mail usecase.Mail) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.sent = append(m.sent, mail)
+	return nil
+}
+
+var tokenPattern = regexp.MustCompile(`\?token=(\S+)`)
+
+// tokenFromMail returns the token in the link of the mail
+func tokenFromMail(t *testing.T, mail usecase.Mail) string {
+	t.Helper()
+	m := tokenPattern.FindStringSubmatch(mail.Body)
+	if m == nil {
+		t.Fatalf("no token in mail: %q", mail.Body)
+	}
+	return m[1]
+}
+
+func TestChangePasswordUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+
+	cases := []struct {
+		name         string
+		input        usecase.ChangePasswordUseCaseInput
+		wantPassword string
+		wantErr      error
+	}{
+		{
+			name:         "Success ChangePassword",
+			input:        usecase.ChangePasswordUseCaseInput{UserID: 1, CurrentPassword: "test01", NewPassword: "new-password"},
+			wantPassword: "hashed:new-password",
+		},
+		{
+			name:         "Current password is incorrect",
+			input:        usecase.ChangePasswordUseCaseInput{UserID: 1, CurrentPassword: "wrong", NewPassword: "new-password"},
+			wantPassword: "hashed:test01",
+			wantErr:      usecase.ErrPasswordMismatch,
+		},
+		{
+			name:         "User not found",
+			input:        usecase.ChangePasswordUseCaseInput{UserID: 2, CurrentPassword: "test01", NewPassword: "new-password"},
+			wantPassword: "hashed:test01",
+			wantErr:      usecase.ErrUserNotFound,
+		},
+		{
+			name:         "New password is too short",
+			input:        usecase.ChangePasswordUseCaseInput{UserID: 1, CurrentPassword: "test01", NewPassword: "short"},
+			wantPassword: "hashed:test01",
+			wantErr:      domain.ErrInvalidPassword,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ur := &TestStubUserRepository{userStore: []domain.User{user01}}
+			pu := usecase.NewPasswordUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, &TestStubMailer{}, usecase.PasswordUseCaseConfig{})
+
+			err := pu.ChangePassword(context.Background(), tt.input)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantPassword, ur.userStore[0].GetPassword())
+		})
+	}
+}
+
+func TestPasswordResetUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	conf := usecase.PasswordUseCaseConfig{
+		ResetURL:      "http://localhost/password-reset",
+		ResetTokenTTL: time.Hour,
+	}
+
+	t.Run("Success password reset", func(t *testing.T) {
+		ur := &TestStubUserRepository{userStore: []domain.User{user01}}
+		sr := &TestStubSessionRepository{}
+		m := &TestStubMailer{}
+		pu := usecase.NewPasswordUseCase(ur, &TestStubUserTokenRepository{}, sr, &TestStubPasswordHasher{}, m, conf)
+
+		err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+		if !assert.NoError(t, err) || !assert.Len(t, m.sent, 1) {
+			return
+		}
+		assert.Equal(t, "test01@test.com", m.sent[0].To)
+		token := tokenFromMail(t, m.sent[0])
+
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: token, NewPassword: "new-password"}
+		err = pu.ConfirmPasswordReset(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hashed:new-password", ur.userStore[0].GetPassword())
+			assert.Equal(t, []domain.UserID{1}, sr.revoked)
+		}
+	})
+
+	t.Run("Token is single-use", func(t *testing.T) {
+		m := &TestStubMailer{}
+		pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+		if !assert.NoError(t, err) {
+			return
+		}
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: tokenFromMail(t, m.sent[0]), NewPassword: "new-password"}
+
+		assert.NoError(t, pu.ConfirmPasswordReset(context.Background(), input))
+		assert.ErrorIs(t, pu.ConfirmPasswordReset(context.Background(), input), usecase.ErrInvalidToken)
+	})
+
+	t.Run("Only the latest token is valid", func(t *testing.T) {
+		m := &TestStubMailer{}
+		pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		for range 2 {
+			err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+			if !assert.NoError(t, err) {
+				return
+			}
+		}
+
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: tokenFromMail(t, m.sent[0]), NewPassword: "new-password"}
+		assert.ErrorIs(t, pu.ConfirmPasswordReset(context.Background(), input), usecase.ErrInvalidToken)
+
+		input.Token = tokenFromMail(t, m.sent[1])
+		assert.NoError(t, pu.ConfirmPasswordReset(context.Background(), input))
+	})
+
+	t.Run("Token is expired", func(t *testing.T) {
+		sr := &TestStubSessionRepository{}
+		m := &TestStubMailer{}
+		expiredConf := conf
+		expiredConf.ResetTokenTTL = -time.Minute
+		pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, sr, &TestStubPasswordHasher{}, m, expiredConf)
+
+		err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: tokenFromMail(t, m.sent[0]), NewPassword: "new-password"}
+		err = pu.ConfirmPasswordReset(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+		assert.Empty(t, sr.revoked)
+	})
+
+	// the response is the same whether a mail is sent or not, so that registered emails cannot be enumerated
+	requestCases := []struct {
+		name           string
+		email          string
+		resendInterval time.Duration
+		mailErr        error
+	}{
+		{name: "Too many requests", email: "test01@test.com", resendInterval: time.Minute},
+		{name: "Unknown email", email: "unknown@test.com"},
+		{name: "Mail failure", email: "test01@test.com", mailErr: errors.New("mail server down")},
+	}
+
+	for _, tt := range requestCases {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &TestStubMailer{}
+			resendConf := conf
+			resendConf.ResetResendInterval = tt.resendInterval
+			pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, resendConf)
+
+			err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+			if !assert.NoError(t, err) {
+				return
+			}
+			m.err = tt.mailErr
+
+			err = pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: tt.email})
+			assert.NoError(t, err)
+			assert.Len(t, m.sent, 1, "only the first mail is sent")
+		})
+	}
+
+	t.Run("Too short password keeps the token", func(t *testing.T) {
+		m := &TestStubMailer{}
+		pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, conf)
+
+		err := pu.RequestPasswordReset(context.Background(), usecase.RequestPasswordResetUseCaseInput{Email: "test01@test.com"})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: tokenFromMail(t, m.sent[0]), NewPassword: "short"}
+		assert.ErrorIs(t, pu.ConfirmPasswordReset(context.Background(), input), domain.ErrInvalidPassword)
+
+		input.NewPassword = "new-password"
+		assert.NoError(t, pu.ConfirmPasswordReset(context.Background(), input))
+	})
+
+	t.Run("Invalid token", func(t *testing.T) {
+		pu := usecase.NewPasswordUseCase(&TestStubUserRepository{userStore: []domain.User{user01}}, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, &TestStubMailer{}, conf)
+
+		input := usecase.ConfirmPasswordResetUseCaseInput{Token: "invalid", NewPassword: "new-password"}
+		err := pu.ConfirmPasswordReset(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrInvalidToken)
+	})
+}
+
+func TestResetPasswordUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+
+	cases := []struct {
+		name         string
+		input        usecase.ResetPasswordUseCaseInput
+		wantPassword string
+		wantRevoked  []domain.UserID
+		wantErr      error
+	}{
+		{
+			name:         "Success ResetPassword",
+			input:        usecase.ResetPasswordUseCaseInput{UserID: 1, NewPassword: "new-password"},
+			wantPassword: "hashed:new-password",
+			wantRevoked:  []domain.UserID{1},
+		},
+		{
+			name:         "User not found",
+			input:        usecase.ResetPasswordUseCaseInput{UserID: 2, NewPassword: "new-password"},
+			wantPassword: "hashed:test01",
+			wantErr:      usecase.ErrUserNotFound,
+		},
+		{
+			name:         "New password is too short",
+			input:        usecase.ResetPasswordUseCaseInput{UserID: 1, NewPassword: "short"},
+			wantPassword: "hashed:test01",
+			wantErr:      domain.ErrInvalidPassword,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ur := &TestStubUserRepository{userStore: []domain.User{user01}}
+			sr := &TestStubSessionRepository{}
+			pu := usecase.NewPasswordUseCase(ur, &TestStubUserTokenRepository{}, sr, &TestStubPasswordHasher{}, &TestStubMailer{}, usecase.PasswordUseCaseConfig{})
+
+			err := pu.ResetPassword(context.Background(), tt.input)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantPassword, ur.userStore[0].GetPassword())
+			assert.Equal(t, tt.wantRevoked, sr.revoked)
+		})
+	}
+}
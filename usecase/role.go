@@ -0,0 +1,134 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+type AuthorizeUseCaseInput struct {
+	UserID     int
+	Permission domain.Permission
+}
+
+type GetUserRolesUseCaseInput struct {
+	UserID int
+}
+
+type GetUserRolesUseCaseOutput struct {
+	Roles       []string
+	Permissions []string
+}
+
+type AssignRoleUseCaseInput struct {
+	UserID int
+	Role   string
+}
+
+type RevokeRoleUseCaseInput struct {
+	// LoginUserID is zero when the role is revoked from the command line
+	LoginUserID int
+	UserID      int
+	Role        string
+}
+
+type IRoleRepository interface {
+	GetRolesByUserID(ctx context.Context, userID domain.UserID) ([]domain.Role, error)
+	// Assign does nothing if the user already has the role
+	Assign(ctx context.Context, userID domain.UserID, role domain.RoleName) error
+	Revoke(ctx context.Context, userID domain.UserID, role domain.RoleName) error
+}
+
+type RoleUseCase struct {
+	ur IUserRepository
+	rr IRoleRepository
+}
+
+func NewRoleUseCase(ur IUserRepository, rr IRoleRepository) *RoleUseCase {
+	return &RoleUseCase{ur: ur, rr: rr}
+}
+
+// Authorize returns ErrForbidden unless one of the roles of the user grants the permission.
+func (ru *RoleUseCase) Authorize(ctx context.Context, input AuthorizeUseCaseInput) error {
+	roles, err := ru.rr.GetRolesByUserID(ctx, domain.UserID(input.UserID))
+	if err != nil {
+		return err
+	}
+	if !domain.HasPermission(roles, input.Permission) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+func (ru *RoleUseCase) GetUserRoles(ctx context.Context, input GetUserRolesUseCaseInput) (*GetUserRolesUseCaseOutput, error) {
+	// check if user exists
+	if _, err := ru.getUser(ctx, input.UserID); err != nil {
+		return nil, err
+	}
+
+	// get roles
+	roles, err := ru.rr.GetRolesByUserID(ctx, domain.UserID(input.UserID))
+	if err != nil {
+		return nil, err
+	}
+
+	output := &GetUserRolesUseCaseOutput{
+		Roles:       make([]string, 0, len(roles)),
+		Permissions: make([]string, 0),
+	}
+	seen := make(map[domain.Permission]bool)
+	for _, role := range roles {
+		output.Roles = append(output.Roles, string(role.GetName()))
+		for _, permission := range role.GetPermissions() {
+			if !seen[permission] {
+				seen[permission] = true
+				output.Permissions = append(output.Permissions, string(permission))
+			}
+		}
+	}
+	return output, nil
+}
+
+func (ru *RoleUseCase) AssignRole(ctx context.Context, input AssignRoleUseCaseInput) error {
+	role, err := domain.NewRole(input.Role)
+	if err != nil {
+		return err
+	}
+
+	// check if user exists
+	if _, err := ru.getUser(ctx, input.UserID); err != nil {
+		return err
+	}
+
+	return ru.rr.Assign(ctx, domain.UserID(input.UserID), role.GetName())
+}
+
+func (ru *RoleUseCase) RevokeRole(ctx context.Context, input RevokeRoleUseCaseInput) error {
+	role, err := domain.NewRole(input.Role)
+	if err != nil {
+		return err
+	}
+
+	// an admin cannot lock themselves out of the admin endpoints
+	if input.LoginUserID == input.UserID && role.HasPermission(domain.PermissionRolesAssign) {
+		return ErrForbidden
+	}
+
+	// check if user exists
+	if _, err := ru.getUser(ctx, input.UserID); err != nil {
+		return err
+	}
+
+	return ru.rr.Revoke(ctx, domain.UserID(input.UserID), role.GetName())
+}
+
+func (ru *RoleUseCase) getUser(ctx context.Context, id int) (*domain.User, error) {
+	user, err := ru.ur.GetUserByID(ctx, domain.UserID(id))
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,175 @@
+package usecase_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+	"github.com/ricky2122/go-echo-example/usecase"
+	"github.com/stretchr/testify/assert"
+)
+
+type TestStubRoleRepository struct {
+	userRoles map[domain.UserID][]domain.RoleName
+}
+
+func (s *TestStubRoleRepository) GetRolesByUserID(_ context.Context, userID domain.UserID) ([]domain.Role, error) {
+	roles := make([]domain.Role, 0)
+	for _, name := range s.userRoles[userID] {
+		role, err := domain.NewRole(string(name))
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, nil
+}
+
+func (s *TestStubRoleRepository) Assign(_ context.Context, userID domain.UserID, role domain.RoleName) error {
+	if s.userRoles == nil {
+		s.userRoles = make(map[domain.UserID][]domain.RoleName)
+	}
+	if !slices.Contains(s.userRoles[userID], role) {
+		s.userRoles[userID] = append(s.userRoles[userID], role)
+	}
+	return nil
+}
+
+func (s *TestStubRoleRepository) Revoke(_ context.Context, userID domain.UserID, role domain.RoleName) error {
+	s.userRoles[userID] = slices.DeleteFunc(s.userRoles[userID], func(r domain.RoleName) bool {
+		return r == role
+	})
+	return nil
+}
+
+func TestAuthorizeUseCase(t *testing.T) {
+	rr := &TestStubRoleRepository{userRoles: map[domain.UserID][]domain.RoleName{
+		1: {domain.RoleAdmin, domain.RoleMember},
+		2: {domain.RoleMember},
+	}}
+	ru := usecase.NewRoleUseCase(&TestStubUserRepository{}, rr)
+
+	cases := []struct {
+		name       string
+		userID     int
+		permission domain.Permission
+		wantErr    error
+	}{
+		{name: "member lists users", userID: 2, permission: domain.PermissionUsersList},
+		{name: "member cannot assign roles", userID: 2, permission: domain.PermissionRolesAssign, wantErr: usecase.ErrForbidden},
+		{name: "admin assigns roles", userID: 1, permission: domain.PermissionRolesAssign},
+		{name: "user without roles", userID: 3, permission: domain.PermissionUsersRead, wantErr: usecase.ErrForbidden},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ru.Authorize(context.Background(), usecase.AuthorizeUseCaseInput{UserID: tt.userID, Permission: tt.permission})
+			assert.Equal(t, tt.wantErr, err)
+		})
+	}
+}
+
+func TestGetUserRolesUseCase(t *testing.T) {
+	user02 := domain.ReconstructUser(2, "test02", "hashed:test02", "test02@test.com", time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	ur := &TestStubUserRepository{userStore: []domain.User{user02}}
+	rr := &TestStubRoleRepository{userRoles: map[domain.UserID][]domain.RoleName{2: {domain.RoleMember}}}
+	ru := usecase.NewRoleUseCase(ur, rr)
+
+	t.Run("Success GetUserRoles", func(t *testing.T) {
+		got, err := ru.GetUserRoles(context.Background(), usecase.GetUserRolesUseCaseInput{UserID: 2})
+		if assert.NoError(t, err) {
+			assert.Equal(t, &usecase.GetUserRolesUseCaseOutput{
+				Roles:       []string{"member"},
+				Permissions: []string{"users:read", "users:list"},
+			}, got)
+		}
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		_, err := ru.GetUserRoles(context.Background(), usecase.GetUserRolesUseCaseInput{UserID: 3})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+	})
+}
+
+func TestAssignRoleUseCase(t *testing.T) {
+	user02 := domain.ReconstructUser(2, "test02", "hashed:test02", "test02@test.com", time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+
+	cases := []struct {
+		name      string
+		input     usecase.AssignRoleUseCaseInput
+		wantRoles []domain.RoleName
+		wantErr   error
+	}{
+		{
+			name:      "Success AssignRole",
+			input:     usecase.AssignRoleUseCaseInput{UserID: 2, Role: "admin"},
+			wantRoles: []domain.RoleName{domain.RoleMember, domain.RoleAdmin},
+		},
+		{
+			name:      "unknown role",
+			input:     usecase.AssignRoleUseCaseInput{UserID: 2, Role: "owner"},
+			wantRoles: []domain.RoleName{domain.RoleMember},
+			wantErr:   domain.ErrUnknownRole,
+		},
+		{
+			name:    "user not found",
+			input:   usecase.AssignRoleUseCaseInput{UserID: 3, Role: "admin"},
+			wantErr: usecase.ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ur := &TestStubUserRepository{userStore: []domain.User{user02}}
+			rr := &TestStubRoleRepository{userRoles: map[domain.UserID][]domain.RoleName{2: {domain.RoleMember}}}
+			ru := usecase.NewRoleUseCase(ur, rr)
+
+			err := ru.AssignRole(context.Background(), tt.input)
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.Equal(t, tt.wantRoles, rr.userRoles[domain.UserID(tt.input.UserID)])
+		})
+	}
+}
+
+func TestRevokeRoleUseCase(t *testing.T) {
+	users := []domain.User{
+		domain.ReconstructUser(1, "test01", "hashed:test01", "test01@test.com", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}),
+		domain.ReconstructUser(2, "test02", "hashed:test02", "test02@test.com", time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC), time.Time{}),
+	}
+
+	cases := []struct {
+		name      string
+		input     usecase.RevokeRoleUseCaseInput
+		wantRoles []domain.RoleName
+		wantErr   error
+	}{
+		{
+			name:      "Success RevokeRole",
+			input:     usecase.RevokeRoleUseCaseInput{LoginUserID: 1, UserID: 2, Role: "member"},
+			wantRoles: []domain.RoleName{},
+		},
+		{
+			name:      "admin cannot revoke own admin role",
+			input:     usecase.RevokeRoleUseCaseInput{LoginUserID: 1, UserID: 1, Role: "admin"},
+			wantRoles: []domain.RoleName{domain.RoleAdmin, domain.RoleMember},
+			wantErr:   usecase.ErrForbidden,
+		},
+	}
+
+	for _, tt := range cases {
+		t.Run(tt.name, func(t *testing.T) {
+			ur := &TestStubUserRepository{userStore: users}
+			rr := &TestStubRoleRepository{userRoles: map[domain.UserID][]domain.RoleName{
+				1: {domain.RoleAdmin, domain.RoleMember},
+				2: {domain.RoleMember},
+			}}
+			ru := usecase.NewRoleUseCase(ur, rr)
+
+			err := ru.RevokeRole(context.Background(), tt.input)
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantRoles, rr.userRoles[domain.UserID(tt.input.UserID)])
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"github.com/ricky2122/go-echo-example/domain"
+)
+
+type IUserTokenRepository interface {
+	Create(ctx context.Context, token domain.UserToken) error
+	// Consume marks the unused and unexpired token as used and returns its user, or nil if there is no such token.
+	Consume(ctx context.Context, purpose domain.UserTokenPurpose, tokenHash string) (*domain.UserID, error)
+	DeleteByUserID(ctx context.Context, userID domain.UserID, purpose domain.UserTokenPurpose) error
+	LatestCreatedAt(ctx context.Context, userID domain.UserID, purpose domain.UserTokenPurpose) (time.Time, error)
+}
+
+// issueToken replaces the user's tokens for the purpose with a new one and returns it,
+// so that only the latest token is valid.
+func issueToken(
+	ctx context.Context,
+	tr IUserTokenRepository,
+	userID domain.UserID,
+	purpose domain.UserTokenPurpose,
+	ttl time.Duration,
+) (string, error) {
+	if err := tr.DeleteByUserID(ctx, userID, purpose); err != nil {
+		return "", err
+	}
+
+	token, tokenHash, err := generateToken()
+	if err != nil {
+		return "", err
+	}
+	if err := tr.Create(ctx, domain.NewUserToken(userID, purpose, tokenHash, time.Now().Add(ttl))); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// generateToken returns a random token to send to the user and its hash to store.
+func generateToken() (token string, tokenHash string, err error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(b)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
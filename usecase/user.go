@@ -3,14 +3,17 @@ package usecase
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/ricky2122/go-echo-example/domain"
 )
 
 var (
-	ErrUserAlreadyExists = errors.New("user already exists")
-	ErrUserNotFound      = errors.New("user not found")
+	ErrUserAlreadyExists  = errors.New("user already exists")
+	ErrEmailAlreadyExists = errors.New("email already exists")
+	ErrUserNotFound       = errors.New("user not found")
+	ErrForbidden          = errors.New("forbidden")
 )
 
 type SignUpUseCaseInput struct {
@@ -34,31 +37,113 @@ type GetUserUseCaseOutput struct {
 	Name     string
 	Email    string
 	BirthDay string
+	Disabled bool
+}
+
+// UpdateUserUseCaseInput holds the fields to change; nil fields are left as they are.
+type UpdateUserUseCaseInput struct {
+	LoginUserID int
+	ID          int
+	Name        *string
+	Email       *string
+	BirthDay    *time.Time
+}
+
+type UpdateUserUseCaseOutput struct {
+	ID       int
+	Name     string
+	Email    string
+	BirthDay string
+}
+
+type DeleteUserUseCaseInput struct {
+	LoginUserID int
+	ID          int
+}
+
+type DisableUserUseCaseInput struct {
+	ID int
+}
+
+type RestoreUserUseCaseInput struct {
+	ID int
+}
+
+type GetUsersUseCaseInput struct {
+	Limit   int
+	Cursor  string
+	SortKey UserSortKey
+	Desc    bool
+	Filter  UserFilter
 }
 
 type GetUsersUseCaseOutput struct {
-	Users []GetUserUseCaseOutput
+	Users      []GetUserUseCaseOutput
+	NextCursor string
+	TotalCount int
 }
 
 type IUserRepository interface {
 	IsExist(ctx context.Context, name string) (bool, error)
-	Create(ctx context.Context, newUser domain.User) (*domain.User, error)
+	IsEmailExist(ctx context.Context, email string) (bool, error)
+	// Create inserts the user together with its roles, so that a user never exists without them
+	Create(ctx context.Context, newUser domain.User, roles []domain.RoleName) (*domain.User, error)
 	GetUserByID(ctx context.Context, id domain.UserID) (*domain.User, error)
-	GetUsers(ctx context.Context) ([]domain.User, error)
+	GetUserByName(ctx context.Context, name string) (*domain.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*domain.User, error)
+	GetUsers(ctx context.Context, query UserListQuery) ([]domain.User, error)
+	CountUsers(ctx context.Context, filter UserFilter) (int, error)
+	Update(ctx context.Context, user domain.User) error
+	UpdatePassword(ctx context.Context, id domain.UserID, hashedPassword string) error
+	Delete(ctx context.Context, id domain.UserID) error
+	Restore(ctx context.Context, id domain.UserID) (bool, error)
+	PurgeDeleted(ctx context.Context, before time.Time) (int, error)
+}
+
+// PasswordHasher hashes passwords into self-describing encoded strings.
+// Verify reports needsRehash when the hash was made with an outdated algorithm or parameters.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(password, hashedPassword string) (matched bool, needsRehash bool, err error)
+}
+
+type UserUseCaseConfig struct {
+	// VerifyEmailURL is the page the verification token is sent to as the "token" query parameter
+	VerifyEmailURL      string
+	VerifyEmailTokenTTL time.Duration
+	// VerifyEmailResendInterval is the minimum interval between verification mails to a user
+	VerifyEmailResendInterval time.Duration
 }
 
 type UserUseCase struct {
 	ur IUserRepository
+	tr IUserTokenRepository
+	// sr ends the sessions of a disabled user
+	sr   ISessionRepository
+	ph   PasswordHasher
+	m    Mailer
+	conf UserUseCaseConfig
 }
 
-func NewUserUseCase(ur IUserRepository) *UserUseCase {
-	return &UserUseCase{ur: ur}
+func NewUserUseCase(
+	ur IUserRepository,
+	tr IUserTokenRepository,
+	sr ISessionRepository,
+	ph PasswordHasher,
+	m Mailer,
+	conf UserUseCaseConfig,
+) *UserUseCase {
+	return &UserUseCase{ur: ur, tr: tr, sr: sr, ph: ph, m: m, conf: conf}
 }
 
-func (uc *UserUseCase) SignUp(input SignUpUseCaseInput) (*SignUpUseCaseOutput, error) {
-	user := domain.NewUser(input.Name, input.Password, input.Email, input.BirthDay)
-
-	ctx := context.Background()
+func (uc *UserUseCase) SignUp(ctx context.Context, input SignUpUseCaseInput) (*SignUpUseCaseOutput, error) {
+	user, err := domain.NewUser(input.Name, input.Email, input.BirthDay)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidatePassword(input.Password); err != nil {
+		return nil, err
+	}
 
 	// check if user already exists
 	isExist, err := uc.ur.IsExist(ctx, user.GetName())
@@ -69,11 +154,30 @@ func (uc *UserUseCase) SignUp(input SignUpUseCaseInput) (*SignUpUseCaseOutput, e
 		return nil, ErrUserAlreadyExists
 	}
 
-	// create user
-	createdUser, err := uc.ur.Create(ctx, user)
+	// check if email is already used
+	isEmailExist, err := uc.ur.IsEmailExist(ctx, user.GetEmail())
 	if err != nil {
 		return nil, err
 	}
+	if isEmailExist {
+		return nil, ErrEmailAlreadyExists
+	}
+
+	// hash password, only for a user that can be created as it is slow on purpose
+	hashedPassword, err := uc.ph.Hash(input.Password)
+	if err != nil {
+		return nil, err
+	}
+	user.SetPassword(hashedPassword)
+
+	// create user, every user is a member
+	createdUser, err := uc.ur.Create(ctx, user, []domain.RoleName{domain.RoleMember})
+	if err != nil {
+		return nil, err
+	}
+
+	// the user can ask for another mail if this one fails
+	uc.trySendVerificationMail(ctx, *createdUser)
 
 	// response
 	output := &SignUpUseCaseOutput{
@@ -84,9 +188,8 @@ func (uc *UserUseCase) SignUp(input SignUpUseCaseInput) (*SignUpUseCaseOutput, e
 	return output, nil
 }
 
-func (uc *UserUseCase) GetUser(input GetUserUseCaseInput) (*GetUserUseCaseOutput, error) {
+func (uc *UserUseCase) GetUser(ctx context.Context, input GetUserUseCaseInput) (*GetUserUseCaseOutput, error) {
 	// get user by UserID
-	ctx := context.Background()
 	userID := domain.UserID(input.ID)
 	user, err := uc.ur.GetUserByID(ctx, userID)
 	if err != nil {
@@ -103,16 +206,205 @@ func (uc *UserUseCase) GetUser(input GetUserUseCaseInput) (*GetUserUseCaseOutput
 		Name:     user.GetName(),
 		Email:    user.GetEmail(),
 		BirthDay: user.GetBirthDay().String(),
+		Disabled: user.IsDisabled(),
 	}
 	return output, nil
 }
 
-func (uc *UserUseCase) GetUsers() (*GetUsersUseCaseOutput, error) {
-	ctx := context.Background()
-	users, err := uc.ur.GetUsers(ctx)
+func (uc *UserUseCase) UpdateUser(ctx context.Context, input UpdateUserUseCaseInput) (*UpdateUserUseCaseOutput, error) {
+	// only the owner may edit the profile
+	if input.LoginUserID != input.ID {
+		return nil, ErrForbidden
+	}
+
+	// get user by UserID
+	user, err := uc.ur.GetUserByID(ctx, domain.UserID(input.ID))
 	if err != nil {
 		return nil, err
 	}
+	if user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// change fields
+	if input.Name != nil && *input.Name != user.GetName() {
+		// check if name is already used by another user (changing only the case of the own name is allowed)
+		if !strings.EqualFold(*input.Name, user.GetName()) {
+			isExist, err := uc.ur.IsExist(ctx, *input.Name)
+			if err != nil {
+				return nil, err
+			}
+			if isExist {
+				return nil, ErrUserAlreadyExists
+			}
+		}
+
+		if err := user.ChangeName(*input.Name); err != nil {
+			return nil, err
+		}
+	}
+	oldEmail := user.GetEmail()
+	if input.Email != nil {
+		if err := user.ChangeEmail(*input.Email); err != nil {
+			return nil, err
+		}
+	}
+	// an email stored in mixed case before the emails were normalized is not changed by lowercasing it
+	emailChanged := !strings.EqualFold(user.GetEmail(), oldEmail)
+	if emailChanged {
+		// check if email is already used by another user
+		isEmailExist, err := uc.ur.IsEmailExist(ctx, user.GetEmail())
+		if err != nil {
+			return nil, err
+		}
+		if isEmailExist {
+			return nil, ErrEmailAlreadyExists
+		}
+	}
+	if input.BirthDay != nil {
+		if err := user.ChangeBirthDay(*input.BirthDay); err != nil {
+			return nil, err
+		}
+	}
+
+	// update user
+	if err := uc.ur.Update(ctx, *user); err != nil {
+		return nil, err
+	}
+
+	// a new address has to be verified again
+	if emailChanged {
+		uc.trySendVerificationMail(ctx, *user)
+	}
+
+	output := &UpdateUserUseCaseOutput{
+		ID:       user.GetID().Int(),
+		Name:     user.GetName(),
+		Email:    user.GetEmail(),
+		BirthDay: user.GetBirthDay().String(),
+	}
+	return output, nil
+}
+
+func (uc *UserUseCase) DeleteUser(ctx context.Context, input DeleteUserUseCaseInput) error {
+	// only the owner may close the account
+	if input.LoginUserID != input.ID {
+		return ErrForbidden
+	}
+
+	// check if user exists
+	userID := domain.UserID(input.ID)
+	user, err := uc.ur.GetUserByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+
+	// soft delete user
+	if err := uc.ur.Delete(ctx, userID); err != nil {
+		return err
+	}
+
+	// end the sessions on all devices, so that a restored user has to log in again
+	return uc.sr.RevokeByUserID(ctx, userID)
+}
+
+// DisableUser keeps the user from logging in and ends their sessions, for administrators.
+// Unlike deletion, the user is kept regardless of the retention period.
+func (uc *UserUseCase) DisableUser(ctx context.Context, input DisableUserUseCaseInput) error {
+	// get user by UserID
+	user, err := uc.ur.GetUserByID(ctx, domain.UserID(input.ID))
+	if err != nil {
+		return err
+	}
+	if user == nil {
+		return ErrUserNotFound
+	}
+	if !user.IsDisabled() {
+		user.SetDisabledAt(time.Now())
+		if err := uc.ur.Update(ctx, *user); err != nil {
+			return err
+		}
+	}
+
+	// revoked after the update, so that no new session is started in between,
+	// and again for a disabled user, in case a previous call failed to revoke them
+	return uc.sr.RevokeByUserID(ctx, user.GetID())
+}
+
+func (uc *UserUseCase) RestoreUser(ctx context.Context, input RestoreUserUseCaseInput) error {
+	restored, err := uc.ur.Restore(ctx, domain.UserID(input.ID))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// PurgeDeletedUsers permanently deletes the users deleted more than retention ago
+// and returns the number of purged users.
+func (uc *UserUseCase) PurgeDeletedUsers(ctx context.Context, retention time.Duration) (int, error) {
+	return uc.ur.PurgeDeleted(ctx, time.Now().Add(-retention))
+}
+
+func (uc *UserUseCase) GetUsers(ctx context.Context, input GetUsersUseCaseInput) (*GetUsersUseCaseOutput, error) {
+	// build query
+	query := UserListQuery{
+		Filter:  input.Filter,
+		SortKey: input.SortKey,
+		Desc:    input.Desc,
+		Limit:   input.Limit,
+	}
+	if query.SortKey == "" {
+		query.SortKey = UserSortByID
+	}
+	if !query.SortKey.IsValid() {
+		return nil, ErrInvalidSortKey
+	}
+	if query.Limit <= 0 {
+		query.Limit = DefaultUsersLimit
+	}
+	query.Limit = min(query.Limit, MaxUsersLimit)
+
+	// the cursor must have been issued for the same order
+	if input.Cursor != "" {
+		cursor, err := decodeUserCursor(input.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if cursor.SortKey != query.SortKey || cursor.Desc != query.Desc {
+			return nil, ErrInvalidCursor
+		}
+		if cursor.SortKey == UserSortByBirthDay {
+			if _, err := time.Parse(domain.BirthDayLayout, cursor.Value); err != nil {
+				return nil, ErrInvalidCursor
+			}
+		}
+		query.After = cursor
+	}
+
+	// fetch one extra user to know whether there is a next page
+	limit := query.Limit
+	query.Limit++
+	users, err := uc.ur.GetUsers(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	totalCount, err := uc.ur.CountUsers(ctx, input.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var nextCursor string
+	if len(users) > limit {
+		users = users[:limit]
+		nextCursor = encodeUserCursor(newUserCursor(query, users[limit-1]))
+	}
 
 	outputUsers := make([]GetUserUseCaseOutput, 0, len(users))
 	for _, user := range users {
@@ -121,11 +413,31 @@ func (uc *UserUseCase) GetUsers() (*GetUsersUseCaseOutput, error) {
 			Name:     user.GetName(),
 			Email:    user.GetEmail(),
 			BirthDay: user.GetBirthDay().String(),
+			Disabled: user.IsDisabled(),
 		}
 		outputUsers = append(outputUsers, outputUser)
 	}
 
-	output := &GetUsersUseCaseOutput{Users: outputUsers}
+	output := &GetUsersUseCaseOutput{
+		Users:      outputUsers,
+		NextCursor: nextCursor,
+		TotalCount: totalCount,
+	}
 
 	return output, nil
 }
+
+func newUserCursor(query UserListQuery, last domain.User) UserCursor {
+	cursor := UserCursor{
+		SortKey: query.SortKey,
+		Desc:    query.Desc,
+		ID:      last.GetID().Int(),
+	}
+	switch query.SortKey {
+	case UserSortByName:
+		cursor.Value = last.GetName()
+	case UserSortByBirthDay:
+		cursor.Value = last.GetBirthDay().String()
+	}
+	return cursor
+}
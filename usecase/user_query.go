@@ -0,0 +1,77 @@
+package usecase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	DefaultUsersLimit = 20
+	MaxUsersLimit     = 100
+)
+
+var (
+	ErrInvalidCursor  = errors.New("invalid cursor")
+	ErrInvalidSortKey = errors.New("invalid sort key")
+)
+
+type UserSortKey string
+
+const (
+	UserSortByID       UserSortKey = "id"
+	UserSortByName     UserSortKey = "name"
+	UserSortByBirthDay UserSortKey = "birth_day"
+)
+
+func (k UserSortKey) IsValid() bool {
+	switch k {
+	case UserSortByID, UserSortByName, UserSortByBirthDay:
+		return true
+	default:
+		return false
+	}
+}
+
+type UserFilter struct {
+	NamePrefix   string
+	EmailDomain  string
+	BirthDayFrom *time.Time
+	BirthDayTo   *time.Time
+}
+
+// UserCursor is the keyset position of the last user in a page.
+// Value holds the sort column of that user and is empty when sorting by id.
+type UserCursor struct {
+	SortKey UserSortKey `json:"s"`
+	Desc    bool        `json:"d,omitempty"`
+	Value   string      `json:"v,omitempty"`
+	ID      int         `json:"id"`
+}
+
+type UserListQuery struct {
+	Filter  UserFilter
+	SortKey UserSortKey
+	Desc    bool
+	Limit   int
+	After   *UserCursor
+}
+
+func encodeUserCursor(cursor UserCursor) string {
+	b, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeUserCursor(s string) (*UserCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor UserCursor
+	if err := json.Unmarshal(b, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
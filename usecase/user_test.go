@@ -3,6 +3,8 @@ package usecase_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 	"time"
 
@@ -12,21 +14,41 @@ import (
 )
 
 type TestStubUserRepository struct {
-	userStore []domain.User
+	userStore    []domain.User
+	deletedStore []TestStubDeletedUser
+	roleStore    map[domain.UserID][]domain.RoleName
+}
+
+type TestStubDeletedUser struct {
+	user      domain.User
+	deletedAt time.Time
 }
 
 func (s *TestStubUserRepository) IsExist(_ context.Context, name string) (bool, error) {
 	for _, user := range s.userStore {
-		if name == user.GetName() {
+		if strings.EqualFold(name, user.GetName()) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *TestStubUserRepository) IsEmailExist(_ context.Context, email string) (bool, error) {
+	for _, user := range s.userStore {
+		if strings.EqualFold(email, user.GetEmail()) {
 			return true, nil
 		}
 	}
 	return false, nil
 }
 
-func (s *TestStubUserRepository) Create(_ context.Context, newUser domain.User) (*domain.User, error) {
+func (s *TestStubUserRepository) Create(_ context.Context, newUser domain.User, roles []domain.RoleName) (*domain.User, error) {
 	newUser.SetID(len(s.userStore) + 1)
 	s.userStore = append(s.userStore, newUser)
+	if s.roleStore == nil {
+		s.roleStore = map[domain.UserID][]domain.RoleName{}
+	}
+	s.roleStore[newUser.GetID()] = roles
 	return &newUser, nil
 }
 
@@ -39,13 +61,135 @@ func (s *TestStubUserRepository) GetUserByID(_ context.Context, userID domain.Us
 	return nil, nil
 }
 
-func (s *TestStubUserRepository) GetUsers(_ context.Context) ([]domain.User, error) {
-	return s.userStore, nil
+func (s *TestStubUserRepository) GetUserByName(_ context.Context, name string) (*domain.User, error) {
+	for _, user := range s.userStore {
+		if name == user.GetName() {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *TestStubUserRepository) GetUserByEmail(_ context.Context, email string) (*domain.User, error) {
+	for _, user := range s.userStore {
+		if email == user.GetEmail() {
+			return &user, nil
+		}
+	}
+	return nil, nil
+}
+
+// GetUsers supports only the ascending order of id
+func (s *TestStubUserRepository) GetUsers(_ context.Context, query usecase.UserListQuery) ([]domain.User, error) {
+	users := []domain.User{}
+	for _, user := range s.userStore {
+		if query.After != nil && user.GetID().Int() <= query.After.ID {
+			continue
+		}
+		if len(users) == query.Limit {
+			break
+		}
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func (s *TestStubUserRepository) CountUsers(_ context.Context, _ usecase.UserFilter) (int, error) {
+	return len(s.userStore), nil
+}
+
+func (s *TestStubUserRepository) Update(_ context.Context, updatedUser domain.User) error {
+	for i, user := range s.userStore {
+		if updatedUser.GetID() == user.GetID() {
+			s.userStore[i] = updatedUser
+		}
+	}
+	return nil
+}
+
+func (s *TestStubUserRepository) UpdatePassword(_ context.Context, userID domain.UserID, hashedPassword string) error {
+	for i, user := range s.userStore {
+		if userID == user.GetID() {
+			s.userStore[i] = domain.ReconstructUser(
+				user.GetID(),
+				user.GetName(),
+				hashedPassword,
+				user.GetEmail(),
+				user.GetBirthDay().Time(),
+				user.GetEmailVerifiedAt(),
+			)
+		}
+	}
+	return nil
+}
+
+func (s *TestStubUserRepository) Delete(_ context.Context, userID domain.UserID) error {
+	for i, user := range s.userStore {
+		if userID == user.GetID() {
+			s.deletedStore = append(s.deletedStore, TestStubDeletedUser{user: user, deletedAt: time.Now()})
+			s.userStore = append(s.userStore[:i], s.userStore[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (s *TestStubUserRepository) Restore(_ context.Context, userID domain.UserID) (bool, error) {
+	for i, deleted := range s.deletedStore {
+		if userID == deleted.user.GetID() {
+			s.userStore = append(s.userStore, deleted.user)
+			s.deletedStore = append(s.deletedStore[:i], s.deletedStore[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (s *TestStubUserRepository) PurgeDeleted(_ context.Context, before time.Time) (int, error) {
+	remaining := []TestStubDeletedUser{}
+	for _, deleted := range s.deletedStore {
+		if !deleted.deletedAt.Before(before) {
+			remaining = append(remaining, deleted)
+		}
+	}
+	purged := len(s.deletedStore) - len(remaining)
+	s.deletedStore = remaining
+	return purged, nil
+}
+
+// TestStubPasswordHasher hashes with a "hashed:" prefix.
+// Hashes with an "outdated:" prefix are accepted but need to be rehashed.
+type TestStubPasswordHasher struct {
+	// hashed counts the calls of Hash
+	hashed int
+	err    error
+}
+
+func (h *TestStubPasswordHasher) Hash(password string) (string, error) {
+	if h.err != nil {
+		return "", h.err
+	}
+	h.hashed++
+	return "hashed:" + password, nil
+}
+
+func (h *TestStubPasswordHasher) Verify(password, hashedPassword string) (bool, bool, error) {
+	if strings.HasPrefix(hashedPassword, "outdated:") {
+		return "outdated:"+password == hashedPassword, true, nil
+	}
+	return "hashed:"+password == hashedPassword, false, nil
+}
+
+func newUserUseCase(ur *TestStubUserRepository) *usecase.UserUseCase {
+	return usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, &TestStubMailer{}, usecase.UserUseCaseConfig{
+		VerifyEmailURL:      "http://localhost/verify-email",
+		VerifyEmailTokenTTL: time.Hour,
+	})
 }
 
 func TestSignUpUseCase(t *testing.T) {
 	t.Run("Success SignUp", func(t *testing.T) {
-		uuc := usecase.NewUserUseCase(&TestStubUserRepository{})
+		uuc := newUserUseCase(&TestStubUserRepository{})
 
 		cases := []struct {
 			name  string
@@ -56,9 +200,9 @@ func TestSignUpUseCase(t *testing.T) {
 				name: "first user",
 				input: usecase.SignUpUseCaseInput{
 					Name:     "test01",
-					Password: "test01",
+					Password: "password01",
 					Email:    "test01@test.com",
-					BirthDay: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+					BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
 				},
 				want: &usecase.SignUpUseCaseOutput{
 					ID:   1,
@@ -69,9 +213,9 @@ func TestSignUpUseCase(t *testing.T) {
 				name: "second user",
 				input: usecase.SignUpUseCaseInput{
 					Name:     "test02",
-					Password: "test02",
+					Password: "password02",
 					Email:    "test02@test.com",
-					BirthDay: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC),
+					BirthDay: time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
 				},
 				want: &usecase.SignUpUseCaseOutput{
 					ID:   2,
@@ -82,50 +226,202 @@ func TestSignUpUseCase(t *testing.T) {
 
 		for _, tt := range cases {
 			t.Run(tt.name, func(t *testing.T) {
-				got, err := uuc.SignUp(tt.input)
+				got, err := uuc.SignUp(context.Background(), tt.input)
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
 			})
 		}
 	})
 
-	t.Run("User already exists", func(t *testing.T) {
-		uuc := usecase.NewUserUseCase(&TestStubUserRepository{})
+	t.Run("User is a member", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := newUserUseCase(ur)
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "test01@test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		got, err := uuc.SignUp(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, []domain.RoleName{domain.RoleMember}, ur.roleStore[domain.UserID(got.ID)])
+		}
+	})
+
+	t.Run("Mail failure does not fail the sign-up", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, &TestStubMailer{err: errors.New("smtp down")}, usecase.UserUseCaseConfig{
+			VerifyEmailURL:      "http://localhost/verify-email",
+			VerifyEmailTokenTTL: time.Hour,
+		})
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "test01@test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		_, err := uuc.SignUp(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Len(t, ur.userStore, 1)
+		}
+	})
+
+	t.Run("Password is hashed", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := newUserUseCase(ur)
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "test01@test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		_, err := uuc.SignUp(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "hashed:password01", ur.userStore[0].GetPassword())
+		}
+	})
+
+	t.Run("Password is too short", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := newUserUseCase(ur)
 
 		input := usecase.SignUpUseCaseInput{
 			Name:     "test01",
 			Password: "test01",
 			Email:    "test01@test.com",
-			BirthDay: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
 		}
 
-		_, _ = uuc.SignUp(input)
-		_, err := uuc.SignUp(input)
+		_, err := uuc.SignUp(context.Background(), input)
+		assert.ErrorIs(t, err, domain.ErrInvalidPassword)
+		assert.Empty(t, ur.userStore)
+	})
+
+	t.Run("User already exists", func(t *testing.T) {
+		uuc := newUserUseCase(&TestStubUserRepository{})
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "test01@test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		_, _ = uuc.SignUp(context.Background(), input)
+		_, err := uuc.SignUp(context.Background(), input)
 		wantErr := errors.New("user already exists")
 		assert.Equal(t, wantErr, err)
 	})
+
+	t.Run("Conflicts are case-insensitive", func(t *testing.T) {
+		uuc := newUserUseCase(&TestStubUserRepository{})
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "test01@test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		_, err := uuc.SignUp(context.Background(), input)
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input.Name = "TEST01"
+		input.Email = "test02@test.com"
+		_, err = uuc.SignUp(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrUserAlreadyExists)
+
+		input.Name = "test02"
+		input.Email = "Test01@test.com"
+		_, err = uuc.SignUp(context.Background(), input)
+		assert.ErrorIs(t, err, usecase.ErrEmailAlreadyExists)
+	})
+
+	t.Run("Email is normalized", func(t *testing.T) {
+		ur := &TestStubUserRepository{}
+		uuc := newUserUseCase(ur)
+
+		input := usecase.SignUpUseCaseInput{
+			Name:     "test01",
+			Password: "password01",
+			Email:    "Test01@Test.com",
+			BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+
+		_, err := uuc.SignUp(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "test01@test.com", ur.userStore[0].GetEmail())
+		}
+	})
+
+	t.Run("Invalid user", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			input   usecase.SignUpUseCaseInput
+			wantErr error
+		}{
+			{
+				name:    "invalid name",
+				input:   usecase.SignUpUseCaseInput{Name: "test 01", Email: "test01@test.com", BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+				wantErr: domain.ErrInvalidUserName,
+			},
+			{
+				name:    "invalid email",
+				input:   usecase.SignUpUseCaseInput{Name: "test01", Email: "test01", BirthDay: time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)},
+				wantErr: domain.ErrInvalidEmail,
+			},
+			{
+				name:    "too young",
+				input:   usecase.SignUpUseCaseInput{Name: "test01", Email: "test01@test.com", BirthDay: time.Now().AddDate(-1, 0, 0)},
+				wantErr: domain.ErrUserTooYoung,
+			},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				ur := &TestStubUserRepository{}
+				ph := &TestStubPasswordHasher{}
+				uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, ph, &TestStubMailer{}, usecase.UserUseCaseConfig{})
+
+				_, err := uuc.SignUp(context.Background(), tt.input)
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.Empty(t, ur.userStore)
+				// the slow hash is not computed for an invalid user
+				assert.Zero(t, ph.hashed)
+			})
+		}
+	})
 }
 
 func TestGetUserUseCase(t *testing.T) {
 	t.Run("Success GetUser", func(t *testing.T) {
-		user01 := domain.NewUser(
+		user01 := domain.ReconstructUser(
+			1,
 			"test01",
 			"test01",
 			"test01@test.com",
 			time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
 		)
-		user01.SetID(1)
 
-		user02 := domain.NewUser(
+		user02 := domain.ReconstructUser(
+			2,
 			"test02",
 			"test02",
 			"test02@test.com",
 			time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
 		)
-		user02.SetID(2)
 
 		users := []domain.User{user01, user02}
-		uuc := usecase.NewUserUseCase(&TestStubUserRepository{userStore: users})
+		uuc := newUserUseCase(&TestStubUserRepository{userStore: users})
 
 		cases := []struct {
 			name  string
@@ -156,7 +452,7 @@ func TestGetUserUseCase(t *testing.T) {
 
 		for _, tt := range cases {
 			t.Run(tt.name, func(t *testing.T) {
-				got, err := uuc.GetUser(tt.input)
+				got, err := uuc.GetUser(context.Background(), tt.input)
 				assert.NoError(t, err)
 				assert.Equal(t, tt.want, got)
 			})
@@ -164,10 +460,10 @@ func TestGetUserUseCase(t *testing.T) {
 	})
 
 	t.Run("user not found", func(t *testing.T) {
-		uuc := usecase.NewUserUseCase(&TestStubUserRepository{})
+		uuc := newUserUseCase(&TestStubUserRepository{})
 		input := usecase.GetUserUseCaseInput{ID: 1}
 
-		_, err := uuc.GetUser(input)
+		_, err := uuc.GetUser(context.Background(), input)
 		assert.Equal(t, usecase.ErrUserNotFound, err)
 	})
 }
@@ -181,7 +477,8 @@ func TestGetUsersUseCase(t *testing.T) {
 			{
 				name: "empty",
 				want: &usecase.GetUsersUseCaseOutput{
-					Users: []usecase.GetUserUseCaseOutput{},
+					Users:      []usecase.GetUserUseCaseOutput{},
+					TotalCount: 0,
 				},
 			},
 			{
@@ -201,6 +498,7 @@ func TestGetUsersUseCase(t *testing.T) {
 							BirthDay: "2002-01-01",
 						},
 					},
+					TotalCount: 2,
 				},
 			},
 		}
@@ -211,28 +509,30 @@ func TestGetUsersUseCase(t *testing.T) {
 			case "empty":
 				store = nil
 			case "two users":
-				user01 := domain.NewUser(
+				user01 := domain.ReconstructUser(
+					1,
 					"test01",
 					"test01",
 					"test01@test.com",
 					time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+					time.Time{},
 				)
-				user01.SetID(1)
 
-				user02 := domain.NewUser(
+				user02 := domain.ReconstructUser(
+					2,
 					"test02",
 					"test02",
 					"test02@test.com",
 					time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
+					time.Time{},
 				)
-				user02.SetID(2)
 
 				store = []domain.User{user01, user02}
 			}
-			uuc := usecase.NewUserUseCase(&TestStubUserRepository{userStore: store})
+			uuc := newUserUseCase(&TestStubUserRepository{userStore: store})
 
 			t.Run(tt.name, func(t *testing.T) {
-				got, err := uuc.GetUsers()
+				got, err := uuc.GetUsers(context.Background(), usecase.GetUsersUseCaseInput{})
 				if assert.NoError(t, err) {
 					assert.Equal(t, tt.want, got)
 				}
@@ -241,3 +541,313 @@ func TestGetUsersUseCase(t *testing.T) {
 		}
 	})
 }
+
+func TestGetUsersUseCasePagination(t *testing.T) {
+	var store []domain.User
+	for i := 1; i <= 3; i++ {
+		user := domain.ReconstructUser(
+			domain.UserID(i),
+			fmt.Sprintf("test%02d", i),
+			"hashed:password",
+			fmt.Sprintf("test%02d@test.com", i),
+			time.Date(2000+i, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+		store = append(store, user)
+	}
+	uuc := newUserUseCase(&TestStubUserRepository{userStore: store})
+
+	t.Run("next page", func(t *testing.T) {
+		// first page
+		got, err := uuc.GetUsers(context.Background(), usecase.GetUsersUseCaseInput{Limit: 2})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Len(t, got.Users, 2)
+		assert.Equal(t, 3, got.TotalCount)
+		assert.NotEmpty(t, got.NextCursor)
+
+		// last page
+		got, err = uuc.GetUsers(context.Background(), usecase.GetUsersUseCaseInput{Limit: 2, Cursor: got.NextCursor})
+		if !assert.NoError(t, err) {
+			return
+		}
+		if assert.Len(t, got.Users, 1) {
+			assert.Equal(t, 3, got.Users[0].ID)
+		}
+		assert.Empty(t, got.NextCursor)
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		_, err := uuc.GetUsers(context.Background(), usecase.GetUsersUseCaseInput{Cursor: "invalid"})
+		assert.Equal(t, usecase.ErrInvalidCursor, err)
+	})
+
+	t.Run("cursor issued for another order", func(t *testing.T) {
+		got, err := uuc.GetUsers(context.Background(), usecase.GetUsersUseCaseInput{Limit: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		input := usecase.GetUsersUseCaseInput{
+			Limit:   1,
+			Cursor:  got.NextCursor,
+			SortKey: usecase.UserSortByName,
+		}
+		_, err = uuc.GetUsers(context.Background(), input)
+		assert.Equal(t, usecase.ErrInvalidCursor, err)
+	})
+}
+
+func TestUpdateUserUseCase(t *testing.T) {
+	newStore := func() []domain.User {
+		user01 := domain.ReconstructUser(
+			1,
+			"test01",
+			"hashed:test01",
+			"test01@test.com",
+			time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+
+		user02 := domain.ReconstructUser(
+			2,
+			"test02",
+			"hashed:test02",
+			"test02@test.com",
+			time.Date(2002, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+
+		return []domain.User{user01, user02}
+	}
+	ptr := func(s string) *string { return &s }
+
+	t.Run("Success UpdateUser", func(t *testing.T) {
+		ur := &TestStubUserRepository{userStore: newStore()}
+		uuc := newUserUseCase(ur)
+
+		birthDay := time.Date(2000, 12, 31, 0, 0, 0, 0, time.UTC)
+		input := usecase.UpdateUserUseCaseInput{
+			LoginUserID: 1,
+			ID:          1,
+			Name:        ptr("updated01"),
+			BirthDay:    &birthDay,
+		}
+		want := &usecase.UpdateUserUseCaseOutput{
+			ID:       1,
+			Name:     "updated01",
+			Email:    "test01@test.com",
+			BirthDay: "2000-12-31",
+		}
+
+		got, err := uuc.UpdateUser(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, want, got)
+			assert.Equal(t, "updated01", ur.userStore[0].GetName())
+			assert.Equal(t, "hashed:test01", ur.userStore[0].GetPassword())
+		}
+	})
+
+	t.Run("Change the case of the own name", func(t *testing.T) {
+		ur := &TestStubUserRepository{userStore: newStore()}
+		uuc := newUserUseCase(ur)
+
+		input := usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Name: ptr("Test01"), Email: ptr("TEST01@test.com")}
+		got, err := uuc.UpdateUser(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "Test01", got.Name)
+			assert.Equal(t, "test01@test.com", got.Email)
+		}
+	})
+
+	t.Run("Lowercase an email stored in mixed case", func(t *testing.T) {
+		user := domain.ReconstructUser(1, "test01", "hashed:test01", "Test01@test.com", time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC), time.Now())
+		ur := &TestStubUserRepository{userStore: []domain.User{user}}
+		m := &TestStubMailer{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, &TestStubSessionRepository{}, &TestStubPasswordHasher{}, m, usecase.UserUseCaseConfig{})
+
+		input := usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Email: ptr("test01@test.com")}
+		got, err := uuc.UpdateUser(context.Background(), input)
+		if assert.NoError(t, err) {
+			assert.Equal(t, "test01@test.com", got.Email)
+			// the address is still verified
+			assert.Empty(t, m.sent)
+			assert.True(t, ur.userStore[0].IsEmailVerified())
+		}
+	})
+
+	t.Run("Failed UpdateUser", func(t *testing.T) {
+		future := time.Now().AddDate(1, 0, 0)
+		cases := []struct {
+			name    string
+			input   usecase.UpdateUserUseCaseInput
+			wantErr error
+		}{
+			{
+				name:    "not owner",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 2, ID: 1, Name: ptr("updated01")},
+				wantErr: usecase.ErrForbidden,
+			},
+			{
+				name:    "user not found",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 3, ID: 3, Name: ptr("updated03")},
+				wantErr: usecase.ErrUserNotFound,
+			},
+			{
+				name:    "name already exists",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Name: ptr("test02")},
+				wantErr: usecase.ErrUserAlreadyExists,
+			},
+			{
+				name:    "name already exists in another case",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Name: ptr("TEST02")},
+				wantErr: usecase.ErrUserAlreadyExists,
+			},
+			{
+				name:    "email already exists",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Email: ptr("Test02@test.com")},
+				wantErr: usecase.ErrEmailAlreadyExists,
+			},
+			{
+				name:    "empty name",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Name: ptr("")},
+				wantErr: domain.ErrInvalidUserName,
+			},
+			{
+				name:    "invalid email",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, Email: ptr("test01")},
+				wantErr: domain.ErrInvalidEmail,
+			},
+			{
+				name:    "birth day in the future",
+				input:   usecase.UpdateUserUseCaseInput{LoginUserID: 1, ID: 1, BirthDay: &future},
+				wantErr: domain.ErrInvalidBirthDay,
+			},
+		}
+
+		for _, tt := range cases {
+			t.Run(tt.name, func(t *testing.T) {
+				ur := &TestStubUserRepository{userStore: newStore()}
+				uuc := newUserUseCase(ur)
+
+				_, err := uuc.UpdateUser(context.Background(), tt.input)
+				assert.Equal(t, tt.wantErr, err)
+				assert.Equal(t, newStore(), ur.userStore)
+			})
+		}
+	})
+}
+
+func TestDeleteUserUseCase(t *testing.T) {
+	newRepository := func() *TestStubUserRepository {
+		user01 := domain.ReconstructUser(
+			1,
+			"test01",
+			"hashed:test01",
+			"test01@test.com",
+			time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+			time.Time{},
+		)
+		return &TestStubUserRepository{userStore: []domain.User{user01}}
+	}
+
+	t.Run("delete and restore", func(t *testing.T) {
+		ur := newRepository()
+		sr := &TestStubSessionRepository{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, sr, &TestStubPasswordHasher{}, &TestStubMailer{}, usecase.UserUseCaseConfig{})
+
+		// delete
+		err := uuc.DeleteUser(context.Background(), usecase.DeleteUserUseCaseInput{LoginUserID: 1, ID: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = uuc.GetUser(context.Background(), usecase.GetUserUseCaseInput{ID: 1})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+		// the sessions are ended, and are not brought back by the restore
+		assert.Equal(t, []domain.UserID{1}, sr.revoked)
+
+		// restore
+		err = uuc.RestoreUser(context.Background(), usecase.RestoreUserUseCaseInput{ID: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+		_, err = uuc.GetUser(context.Background(), usecase.GetUserUseCaseInput{ID: 1})
+		assert.NoError(t, err)
+	})
+
+	t.Run("not owner", func(t *testing.T) {
+		ur := newRepository()
+		uuc := newUserUseCase(ur)
+
+		err := uuc.DeleteUser(context.Background(), usecase.DeleteUserUseCaseInput{LoginUserID: 2, ID: 1})
+		assert.Equal(t, usecase.ErrForbidden, err)
+		assert.Len(t, ur.userStore, 1)
+	})
+
+	t.Run("restore user not deleted", func(t *testing.T) {
+		uuc := newUserUseCase(newRepository())
+
+		err := uuc.RestoreUser(context.Background(), usecase.RestoreUserUseCaseInput{ID: 1})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+	})
+
+	t.Run("purge after retention", func(t *testing.T) {
+		ur := newRepository()
+		uuc := newUserUseCase(ur)
+
+		err := uuc.DeleteUser(context.Background(), usecase.DeleteUserUseCaseInput{LoginUserID: 1, ID: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+
+		// within retention
+		purged, err := uuc.PurgeDeletedUsers(context.Background(), time.Hour)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, purged)
+
+		// after retention
+		purged, err = uuc.PurgeDeletedUsers(context.Background(), 0)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, purged)
+		assert.Empty(t, ur.deletedStore)
+	})
+}
+
+func TestDisableUserUseCase(t *testing.T) {
+	user01 := domain.ReconstructUser(
+		1,
+		"test01",
+		"hashed:test01",
+		"test01@test.com",
+		time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Time{},
+	)
+
+	t.Run("Success DisableUser", func(t *testing.T) {
+		ur := &TestStubUserRepository{userStore: []domain.User{user01}}
+		sr := &TestStubSessionRepository{}
+		uuc := usecase.NewUserUseCase(ur, &TestStubUserTokenRepository{}, sr, &TestStubPasswordHasher{}, &TestStubMailer{}, usecase.UserUseCaseConfig{})
+
+		err := uuc.DisableUser(context.Background(), usecase.DisableUserUseCaseInput{ID: 1})
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.True(t, ur.userStore[0].IsDisabled())
+		// the sessions are ended
+		assert.Equal(t, []domain.UserID{1}, sr.revoked)
+
+		// the user is kept
+		got, err := uuc.GetUser(context.Background(), usecase.GetUserUseCaseInput{ID: 1})
+		if assert.NoError(t, err) {
+			assert.True(t, got.Disabled)
+		}
+	})
+
+	t.Run("user not found", func(t *testing.T) {
+		uuc := newUserUseCase(&TestStubUserRepository{userStore: []domain.User{user01}})
+
+		err := uuc.DisableUser(context.Background(), usecase.DisableUserUseCaseInput{ID: 2})
+		assert.Equal(t, usecase.ErrUserNotFound, err)
+	})
+}